@@ -0,0 +1,117 @@
+package apikey
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stuckinforloop/ticker/internal/apikey"
+	"github.com/stuckinforloop/ticker/internal/tenant"
+	"github.com/stuckinforloop/ticker/worker"
+)
+
+// ApiKeyCmd represents the apikey command
+var ApiKeyCmd = &cobra.Command{
+	Use:   "apikey",
+	Short: "manages api keys for the http api",
+}
+
+var createCmd = &cobra.Command{
+	Use:   "create",
+	Short: "creates an api key, the key is only shown once",
+	Run: func(cmd *cobra.Command, args []string) {
+		tenantID, _ := cmd.Flags().GetString("tenant")
+		name, _ := cmd.Flags().GetString("name")
+		scope, _ := cmd.Flags().GetString("scope")
+		expiresIn, _ := cmd.Flags().GetDuration("expires-in")
+
+		w := worker.New()
+
+		t, err := tenant.NewTenantDAO(w.DAO).GetTenant(cmd.Context(), tenantID)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if t == nil {
+			log.Fatalf("no tenant with id %s", tenantID)
+		}
+
+		var expiresAt *int64
+		if expiresIn > 0 {
+			at := w.DAO.TimeNow() + int64(expiresIn/time.Second)
+			expiresAt = &at
+		}
+
+		k, key, err := apikey.NewAPIKeyDAO(w.DAO).CreateKey(
+			cmd.Context(), t.ID, name, apikey.Scope(scope), expiresAt)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("id:     %s\n", k.ID)
+		fmt.Printf("tenant: %s\n", t.Name)
+		fmt.Printf("scope:  %s\n", k.Scope)
+		fmt.Printf("key:    %s\n", key)
+	},
+}
+
+var revokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "revokes an api key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		w := worker.New()
+		revoked, err := apikey.NewAPIKeyDAO(w.DAO).RevokeKey(cmd.Context(), args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if !revoked {
+			log.Fatalf("no active api key with id %s", args[0])
+		}
+
+		fmt.Printf("revoked %s\n", args[0])
+	},
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "lists api keys",
+	Run: func(cmd *cobra.Command, args []string) {
+		w := worker.New()
+		keys, err := apikey.NewAPIKeyDAO(w.DAO).ListKeys(cmd.Context())
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tTENANT\tNAME\tPREFIX\tSCOPE\tEXPIRES\tLAST USED\tREVOKED")
+		for _, k := range keys {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				k.ID, k.TenantID, k.Name, k.Prefix, k.Scope,
+				formatTime(k.ExpiresAt), formatTime(k.LastUsedAt), formatTime(k.RevokedAt))
+		}
+		tw.Flush()
+	},
+}
+
+func formatTime(at *int64) string {
+	if at == nil {
+		return "-"
+	}
+
+	return time.Unix(*at, 0).UTC().Format(time.RFC3339)
+}
+
+func init() {
+	createCmd.Flags().String("tenant", tenant.DefaultID, "id of the tenant the key gives access to")
+	createCmd.Flags().String("name", "", "name of the key, e.g. who or what uses it")
+	createCmd.Flags().String("scope", string(apikey.ScopeWrite), `"read" or "write"`)
+	createCmd.Flags().Duration("expires-in", 0, "lifetime of the key, e.g. 720h (default never expires)")
+	createCmd.MarkFlagRequired("name")
+
+	ApiKeyCmd.AddCommand(createCmd, revokeCmd, listCmd)
+}
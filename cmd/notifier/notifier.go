@@ -1,6 +1,8 @@
 package notifier
 
 import (
+	"log"
+
 	"github.com/spf13/cobra"
 	taskexec "github.com/stuckinforloop/ticker/internal/task_exec"
 	"github.com/stuckinforloop/ticker/worker"
@@ -13,6 +15,9 @@ var NotifierCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		w := worker.New()
 		taskExecDAO := taskexec.NewTaskExecDAO(w.DAO)
+		if err := taskExecDAO.SetupNotifier(); err != nil {
+			log.Fatal(err)
+		}
 		w.Run(taskExecDAO.UpdateTaskStatusNotify)
 	},
 }
@@ -7,10 +7,13 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/stuckinforloop/ticker/cmd/apikey"
 	"github.com/stuckinforloop/ticker/cmd/executor"
 	"github.com/stuckinforloop/ticker/cmd/notifier"
 	"github.com/stuckinforloop/ticker/cmd/scheduler"
 	"github.com/stuckinforloop/ticker/cmd/server"
+	"github.com/stuckinforloop/ticker/cmd/standalone"
+	"github.com/stuckinforloop/ticker/cmd/tenant"
 )
 
 var cfgFile string
@@ -76,4 +79,7 @@ func addSubCommands() {
 	rootCmd.AddCommand(scheduler.SchedulerCmd)
 	rootCmd.AddCommand(notifier.NotifierCmd)
 	rootCmd.AddCommand(server.ServerCmd)
+	rootCmd.AddCommand(standalone.StandaloneCmd)
+	rootCmd.AddCommand(apikey.ApiKeyCmd)
+	rootCmd.AddCommand(tenant.TenantCmd)
 }
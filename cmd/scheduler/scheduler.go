@@ -1,6 +1,8 @@
 package scheduler
 
 import (
+	"log"
+
 	"github.com/spf13/cobra"
 	taskexec "github.com/stuckinforloop/ticker/internal/task_exec"
 	"github.com/stuckinforloop/ticker/worker"
@@ -13,7 +15,10 @@ var SchedulerCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		w := worker.New()
 		taskExecDAO := taskexec.NewTaskExecDAO(w.DAO)
-		w.Run(taskExecDAO.ScheduleTasks)
+		if err := taskExecDAO.SetupNotifier(); err != nil {
+			log.Fatal(err)
+		}
+		w.Run(taskExecDAO.ScheduleTasks, taskExecDAO.RelayOutbox)
 	},
 }
 
@@ -0,0 +1,46 @@
+package standalone
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	taskexec "github.com/stuckinforloop/ticker/internal/task_exec"
+	"github.com/stuckinforloop/ticker/server"
+	"github.com/stuckinforloop/ticker/worker"
+)
+
+// StandaloneCmd represents the standalone command
+var StandaloneCmd = &cobra.Command{
+	Use:   "standalone",
+	Short: "starts server, scheduler, executor and notifier in a single process",
+	Run: func(cmd *cobra.Command, args []string) {
+		// bound here rather than in init so that it does not
+		// override the flag of the server command
+		viper.BindPFlag("port", cmd.Flags().Lookup("port"))
+
+		// the handlers share one process, the broker of the config
+		// file is meant for separate processes and is ignored
+		broker, _ := cmd.Flags().GetString("queue-broker")
+		viper.Set("queue_broker", broker)
+
+		w := worker.New()
+		srv := &server.Server{DAO: w.DAO}
+		taskExecDAO := taskexec.NewTaskExecDAO(w.DAO)
+		if err := taskExecDAO.SetupNotifier(); err != nil {
+			log.Fatal(err)
+		}
+		w.Run(
+			srv.Serve,
+			taskExecDAO.ScheduleTasks,
+			taskExecDAO.RelayOutbox,
+			taskExecDAO.ExecuteTasks,
+			taskExecDAO.UpdateTaskStatusNotify,
+		)
+	},
+}
+
+func init() {
+	StandaloneCmd.Flags().IntP("port", "p", 9000, "port to listen for requests")
+	StandaloneCmd.Flags().String("queue-broker", "memory", "queue broker shared by the handlers")
+}
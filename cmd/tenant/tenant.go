@@ -0,0 +1,192 @@
+package tenant
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stuckinforloop/ticker/internal/quota"
+	"github.com/stuckinforloop/ticker/internal/tenant"
+	"github.com/stuckinforloop/ticker/worker"
+)
+
+// TenantCmd represents the tenant command
+var TenantCmd = &cobra.Command{
+	Use:   "tenant",
+	Short: "manages tenants, every tenant has its own groups, tasks and api keys",
+}
+
+var createCmd = &cobra.Command{
+	Use:   "create",
+	Short: "creates a tenant",
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+
+		w := worker.New()
+		t, err := tenant.NewTenantDAO(w.DAO).CreateTenant(cmd.Context(), &tenant.Tenant{
+			Name:      name,
+			ExpiresAt: expiresAt(cmd, w.DAO.TimeNow()),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("id:   %s\n", t.ID)
+		fmt.Printf("name: %s\n", t.Name)
+	},
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "lists tenants",
+	Run: func(cmd *cobra.Command, args []string) {
+		w := worker.New()
+		tenants, err := tenant.NewTenantDAO(w.DAO).ListTenants(cmd.Context())
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tSTATUS\tEXPIRES")
+		for _, t := range tenants {
+			expires := "-"
+			if t.ExpiresAt != nil {
+				expires = time.Unix(*t.ExpiresAt, 0).UTC().Format(time.RFC3339)
+			}
+
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", t.ID, t.Name, t.Status, expires)
+		}
+		tw.Flush()
+	},
+}
+
+var expireCmd = &cobra.Command{
+	Use:   "expire <id>",
+	Short: "expires a tenant, its tasks stop running and its api keys are rejected",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		w := worker.New()
+		expired, err := tenant.NewTenantDAO(w.DAO).ExpireTenant(cmd.Context(), args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if !expired {
+			log.Fatalf("no active tenant with id %s", args[0])
+		}
+
+		fmt.Printf("expired %s\n", args[0])
+	},
+}
+
+var activateCmd = &cobra.Command{
+	Use:   "activate <id>",
+	Short: "re-activates an expired tenant along with its tasks",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		w := worker.New()
+		activated, err := tenant.NewTenantDAO(w.DAO).ActivateTenant(
+			cmd.Context(), args[0], expiresAt(cmd, w.DAO.TimeNow()))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if !activated {
+			log.Fatalf("no expired tenant with id %s", args[0])
+		}
+
+		fmt.Printf("activated %s\n", args[0])
+	},
+}
+
+var quotasCmd = &cobra.Command{
+	Use:   "quotas <id>",
+	Short: "shows the quotas of a tenant, flags override the configured quotas",
+	Long: `shows the quotas of a tenant. Flags set a quota of the tenant,
+0 means unlimited and a negative value resets the quota to the configured one.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		w := worker.New()
+		quotaDAO := quota.NewQuotaDAO(w.DAO)
+
+		t, err := tenant.NewTenantDAO(w.DAO).GetTenant(cmd.Context(), args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if t == nil {
+			log.Fatalf("no tenant with id %s", args[0])
+		}
+
+		o, err := quotaDAO.GetOverrides(cmd.Context(), t.ID)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		changed := false
+		for flag, override := range map[string]**int{
+			"max-tasks":               &o.MaxTasks,
+			"min-interval":            &o.MinInterval,
+			"max-executions-per-hour": &o.MaxExecutionsPerHour,
+			"max-timeout":             &o.MaxTimeout,
+		} {
+			if !cmd.Flags().Changed(flag) {
+				continue
+			}
+			changed = true
+
+			v, _ := cmd.Flags().GetInt(flag)
+			if v < 0 {
+				*override = nil
+			} else {
+				*override = &v
+			}
+		}
+
+		if changed {
+			if _, err := quotaDAO.SetOverrides(cmd.Context(), t.ID, o); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		q, err := quotaDAO.GetQuotas(cmd.Context(), t.ID)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("max_tasks:               %d\n", q.MaxTasks)
+		fmt.Printf("min_interval:            %d\n", q.MinInterval)
+		fmt.Printf("max_executions_per_hour: %d\n", q.MaxExecutionsPerHour)
+		fmt.Printf("max_timeout:             %d\n", q.MaxTimeout)
+	},
+}
+
+// expiresAt returns the expiry set by the --expires-in flag, nil for never
+func expiresAt(cmd *cobra.Command, now int64) *int64 {
+	expiresIn, _ := cmd.Flags().GetDuration("expires-in")
+	if expiresIn <= 0 {
+		return nil
+	}
+
+	at := now + int64(expiresIn/time.Second)
+	return &at
+}
+
+func init() {
+	createCmd.Flags().String("name", "", "name of the tenant, e.g. the team using it")
+	createCmd.MarkFlagRequired("name")
+
+	for _, cmd := range []*cobra.Command{createCmd, activateCmd} {
+		cmd.Flags().Duration("expires-in", 0, "time until the tenant expires, e.g. 720h (default never expires)")
+	}
+
+	quotasCmd.Flags().Int("max-tasks", 0, "number of tasks of the tenant")
+	quotasCmd.Flags().Int("min-interval", 0, "seconds between two runs of a task")
+	quotasCmd.Flags().Int("max-executions-per-hour", 0, "executions fired in any hour")
+	quotasCmd.Flags().Int("max-timeout", 0, "timeout of a task in seconds")
+
+	TenantCmd.AddCommand(createCmd, listCmd, expireCmd, activateCmd, quotasCmd)
+}
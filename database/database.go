@@ -34,7 +34,7 @@ func New() (*DB, error) {
 		return nil, fmt.Errorf("ping db connection: %w", err)
 	}
 
-	if err := runMigrations(db); err != nil {
+	if err := runMigrations(db, "file://database/migrations"); err != nil {
 		return nil, fmt.Errorf("run migrations: %w", err)
 	}
 
@@ -45,7 +45,7 @@ func New() (*DB, error) {
 	}, nil
 }
 
-func runMigrations(db *sql.DB) error {
+func runMigrations(db *sql.DB, sourceURL string) error {
 	driver, err := postgres.WithInstance(db, &postgres.Config{})
 	if err != nil {
 		return fmt.Errorf("could not create the postgres driver: %v", err)
@@ -56,9 +56,7 @@ func runMigrations(db *sql.DB) error {
 	// 	return fmt.Errorf("could not get absolute path to migrations directory: %v", err)
 	// }
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://database/migrations",
-		"postgres", driver)
+	m, err := migrate.NewWithDatabaseInstance(sourceURL, "postgres", driver)
 	if err != nil {
 		return fmt.Errorf("could not create the migrate instance: %v", err)
 	}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS queue_dedupes;
+
+DROP TABLE IF EXISTS queue_messages;
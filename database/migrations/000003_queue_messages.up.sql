@@ -0,0 +1,29 @@
+-- Create the "queue_messages" table used by the postgres queue broker
+CREATE TABLE queue_messages (
+    id BIGSERIAL PRIMARY KEY,
+    queue_name VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    group_id VARCHAR(255),
+    receipt_handle VARCHAR(64),
+    receive_count INT NOT NULL DEFAULT 0,
+    visible_at BIGINT NOT NULL,
+    created_at BIGINT NOT NULL
+);
+
+-- Create an index to find visible messages of a queue in order
+CREATE INDEX ON queue_messages (queue_name, visible_at, id);
+
+-- Create an index to find the head message of a group
+CREATE INDEX ON queue_messages (queue_name, group_id, id);
+
+-- Create unique index on the "receipt_handle" column
+CREATE UNIQUE INDEX ON queue_messages (receipt_handle);
+
+-- Create the "queue_dedupes" table to remember deduplication ids
+CREATE TABLE queue_dedupes (
+    queue_name VARCHAR(255) NOT NULL,
+    dedupe_id VARCHAR(255) NOT NULL,
+    message_id BIGINT NOT NULL,
+    expires_at BIGINT NOT NULL,
+    PRIMARY KEY (queue_name, dedupe_id)
+);
@@ -0,0 +1,8 @@
+ALTER TABLE task_execs
+    DROP COLUMN IF EXISTS error,
+    DROP COLUMN IF EXISTS attempts,
+    DROP COLUMN IF EXISTS attempt;
+
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS max_attempts,
+    DROP COLUMN IF EXISTS retry_backoff;
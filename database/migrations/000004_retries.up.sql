@@ -0,0 +1,10 @@
+-- Add retry settings to the "tasks" table
+ALTER TABLE tasks
+    ADD COLUMN retry_backoff VARCHAR(20) NOT NULL DEFAULT 'fixed',
+    ADD COLUMN max_attempts INT NOT NULL DEFAULT 3;
+
+-- Record every attempt against the "task_execs" table
+ALTER TABLE task_execs
+    ADD COLUMN attempt INT NOT NULL DEFAULT 1,
+    ADD COLUMN attempts JSONB,
+    ADD COLUMN error TEXT;
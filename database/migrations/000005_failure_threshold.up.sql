@@ -0,0 +1,5 @@
+-- Track consecutive failures and why a task was disabled
+ALTER TABLE tasks
+    ADD COLUMN consecutive_failures INT NOT NULL DEFAULT 0,
+    ADD COLUMN disabled_reason TEXT,
+    ADD COLUMN disabled_at BIGINT;
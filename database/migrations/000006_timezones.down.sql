@@ -0,0 +1,3 @@
+UPDATE tasks SET timezone = 'utc';
+
+ALTER TABLE tasks ALTER COLUMN timezone TYPE CHAR(3);
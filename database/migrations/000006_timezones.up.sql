@@ -0,0 +1,4 @@
+-- Widen the "timezone" column to hold IANA timezone names
+ALTER TABLE tasks ALTER COLUMN timezone TYPE VARCHAR(64);
+
+UPDATE tasks SET timezone = 'UTC' WHERE timezone = 'utc';
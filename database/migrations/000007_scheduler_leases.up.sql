@@ -0,0 +1,8 @@
+-- Create the "scheduler_leases" table used for leader election
+CREATE TABLE scheduler_leases (
+    name VARCHAR(64) PRIMARY KEY,
+    holder VARCHAR(255) NOT NULL,
+    acquired_at BIGINT NOT NULL,
+    renewed_at BIGINT NOT NULL,
+    expires_at BIGINT NOT NULL
+);
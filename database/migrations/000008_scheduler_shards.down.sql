@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS scheduler_instances;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS shard_key;
@@ -0,0 +1,15 @@
+-- Spread tasks over 1024 fixed partitions, schedulers own shards of them
+ALTER TABLE tasks
+    ADD COLUMN shard_key INT GENERATED ALWAYS AS (
+        (mod(hashtext(id)::BIGINT & 2147483647, 1024))::INT
+    ) STORED;
+
+-- Create an index on the "status" and "shard_key" columns
+CREATE INDEX ON tasks (status, shard_key);
+
+-- Create the "scheduler_instances" table to track live schedulers
+CREATE TABLE scheduler_instances (
+    id VARCHAR(255) PRIMARY KEY,
+    started_at BIGINT NOT NULL,
+    heartbeat_at BIGINT NOT NULL
+);
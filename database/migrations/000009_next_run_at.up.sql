@@ -0,0 +1,5 @@
+-- Track the next time a task is due, NULL until the scheduler computes it
+ALTER TABLE tasks ADD COLUMN next_run_at BIGINT;
+
+-- Create an index on the "status" and "next_run_at" columns
+CREATE INDEX ON tasks (status, next_run_at);
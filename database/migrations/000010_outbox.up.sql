@@ -0,0 +1,16 @@
+-- Create the "outbox" table holding messages to publish to the queue broker
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    queue_name VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    dedupe_id VARCHAR(255),
+    group_id VARCHAR(255),
+    delay BIGINT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    available_at BIGINT NOT NULL,
+    created_at BIGINT NOT NULL
+);
+
+-- Create an index to find messages ready to be published
+CREATE INDEX ON outbox (available_at, id);
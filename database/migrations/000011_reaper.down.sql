@@ -0,0 +1 @@
+DROP INDEX IF EXISTS task_execs_unfinished_idx;
@@ -0,0 +1,3 @@
+-- Create an index to find executions that have not finished yet
+CREATE INDEX task_execs_unfinished_idx ON task_execs (run_at)
+WHERE status IN ('pending', 'running', 'retrying');
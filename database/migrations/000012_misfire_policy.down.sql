@@ -0,0 +1,5 @@
+DELETE FROM task_execs WHERE status = 'missed';
+
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS misfire_limit,
+    DROP COLUMN IF EXISTS misfire_policy;
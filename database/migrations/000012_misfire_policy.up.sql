@@ -0,0 +1,4 @@
+-- Add the policy applied to runs missed while no scheduler was running
+ALTER TABLE tasks
+    ADD COLUMN misfire_policy VARCHAR(20) NOT NULL DEFAULT 'skip',
+    ADD COLUMN misfire_limit INT NOT NULL DEFAULT 10;
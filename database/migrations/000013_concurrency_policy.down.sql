@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS task_execs_running_idx;
+
+ALTER TABLE task_execs
+    DROP COLUMN IF EXISTS reason;
+
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS concurrency_policy;
@@ -0,0 +1,11 @@
+-- Add the policy applied when a task already has "instances" running executions
+ALTER TABLE tasks
+    ADD COLUMN concurrency_policy VARCHAR(20) NOT NULL DEFAULT 'allow';
+
+-- Record why an execution was skipped or cancelled
+ALTER TABLE task_execs
+    ADD COLUMN reason TEXT;
+
+-- Create an index to count the running executions of a task
+CREATE INDEX task_execs_running_idx ON task_execs (task_id)
+WHERE status = 'running';
@@ -0,0 +1,7 @@
+DELETE FROM task_execs WHERE manual;
+
+DROP INDEX IF EXISTS task_execs_task_id_run_at_idx;
+CREATE UNIQUE INDEX task_execs_task_id_run_at_idx ON task_execs (task_id, run_at);
+
+ALTER TABLE task_execs
+    DROP COLUMN IF EXISTS manual;
@@ -0,0 +1,8 @@
+-- Flag executions triggered manually outside of the cron schedule
+ALTER TABLE task_execs
+    ADD COLUMN manual BOOLEAN NOT NULL DEFAULT false;
+
+-- Only scheduled runs are unique per "run_at"
+DROP INDEX IF EXISTS task_execs_task_id_run_at_idx;
+CREATE UNIQUE INDEX task_execs_task_id_run_at_idx ON task_execs (task_id, run_at)
+WHERE NOT manual;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS task_execs_task_id_id_idx;
@@ -0,0 +1,2 @@
+-- Create an index to page through the executions of a task
+CREATE INDEX task_execs_task_id_id_idx ON task_execs (task_id, id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tasks_group_id_idx;
+DROP INDEX IF EXISTS tasks_updated_at_id_idx;
+DROP INDEX IF EXISTS tasks_created_at_id_idx;
@@ -0,0 +1,4 @@
+-- Create indexes to page through tasks in the supported sort orders
+CREATE INDEX tasks_created_at_id_idx ON tasks (created_at, id);
+CREATE INDEX tasks_updated_at_id_idx ON tasks (updated_at, id);
+CREATE INDEX tasks_group_id_idx ON tasks (group_id);
@@ -0,0 +1,4 @@
+-- Track changes to the "groups" table
+ALTER TABLE groups
+    ADD COLUMN created_at BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN updated_at BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS tasks_resume_at_idx;
+
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS resume_at,
+    DROP COLUMN IF EXISTS pause_reason,
+    DROP COLUMN IF EXISTS paused_at,
+    DROP COLUMN IF EXISTS paused_by;
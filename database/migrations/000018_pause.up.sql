@@ -0,0 +1,10 @@
+-- Record who paused a task, why and until when
+ALTER TABLE tasks
+    ADD COLUMN paused_by TEXT,
+    ADD COLUMN paused_at BIGINT,
+    ADD COLUMN pause_reason TEXT,
+    ADD COLUMN resume_at BIGINT;
+
+-- Create an index to find the paused tasks to resume
+CREATE INDEX tasks_resume_at_idx ON tasks (resume_at)
+WHERE resume_at IS NOT NULL;
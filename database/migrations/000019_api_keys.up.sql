@@ -0,0 +1,12 @@
+-- Create the "api_keys" table, only the sha256 hash of a key is stored
+CREATE TABLE api_keys (
+    id CHAR(26) PRIMARY KEY,
+    name TEXT NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    scope VARCHAR(10) NOT NULL,
+    expires_at BIGINT,
+    last_used_at BIGINT,
+    revoked_at BIGINT,
+    created_at BIGINT NOT NULL
+);
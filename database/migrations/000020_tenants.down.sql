@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS groups_tenant_id_idx;
+DROP INDEX IF EXISTS task_execs_tenant_id_id_idx;
+DROP INDEX IF EXISTS tasks_tenant_id_updated_at_id_idx;
+DROP INDEX IF EXISTS tasks_tenant_id_created_at_id_idx;
+CREATE INDEX tasks_created_at_id_idx ON tasks (created_at, id);
+CREATE INDEX tasks_updated_at_id_idx ON tasks (updated_at, id);
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE task_execs DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE tasks DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE groups DROP COLUMN IF EXISTS tenant_id;
+
+DROP TABLE IF EXISTS tenants;
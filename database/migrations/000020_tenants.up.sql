@@ -0,0 +1,40 @@
+-- Create the "tenants" table, groups, tasks, executions and
+-- api keys belong to a tenant
+CREATE TABLE tenants (
+    id CHAR(26) PRIMARY KEY,
+    name TEXT NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    expires_at BIGINT,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+-- Existing rows belong to the default tenant
+INSERT INTO tenants (id, name, status, created_at, updated_at)
+VALUES (
+    '00000000000000000000000000', 'default', 'active',
+    EXTRACT(EPOCH FROM now())::BIGINT, EXTRACT(EPOCH FROM now())::BIGINT
+);
+
+ALTER TABLE groups
+    ADD COLUMN tenant_id CHAR(26) NOT NULL DEFAULT '00000000000000000000000000' REFERENCES tenants (id);
+ALTER TABLE tasks
+    ADD COLUMN tenant_id CHAR(26) NOT NULL DEFAULT '00000000000000000000000000' REFERENCES tenants (id);
+ALTER TABLE task_execs
+    ADD COLUMN tenant_id CHAR(26) NOT NULL DEFAULT '00000000000000000000000000' REFERENCES tenants (id);
+ALTER TABLE api_keys
+    ADD COLUMN tenant_id CHAR(26) NOT NULL DEFAULT '00000000000000000000000000' REFERENCES tenants (id);
+
+-- New rows name their tenant
+ALTER TABLE groups ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE tasks ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE task_execs ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE api_keys ALTER COLUMN tenant_id DROP DEFAULT;
+
+-- Tasks and executions are listed within a tenant
+DROP INDEX IF EXISTS tasks_created_at_id_idx;
+DROP INDEX IF EXISTS tasks_updated_at_id_idx;
+CREATE INDEX tasks_tenant_id_created_at_id_idx ON tasks (tenant_id, created_at, id);
+CREATE INDEX tasks_tenant_id_updated_at_id_idx ON tasks (tenant_id, updated_at, id);
+CREATE INDEX task_execs_tenant_id_id_idx ON task_execs (tenant_id, id);
+CREATE INDEX groups_tenant_id_idx ON groups (tenant_id);
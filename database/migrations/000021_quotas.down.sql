@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS task_execs_tenant_id_created_at_idx;
+
+ALTER TABLE tenants
+    DROP COLUMN IF EXISTS max_timeout,
+    DROP COLUMN IF EXISTS max_executions_per_hour,
+    DROP COLUMN IF EXISTS min_interval,
+    DROP COLUMN IF EXISTS max_tasks;
@@ -0,0 +1,9 @@
+-- Quotas of a tenant, NULL falls back to the configured quota
+ALTER TABLE tenants
+    ADD COLUMN max_tasks INT,
+    ADD COLUMN min_interval INT,
+    ADD COLUMN max_executions_per_hour INT,
+    ADD COLUMN max_timeout INT;
+
+-- Create an index to count the executions fired by a tenant recently
+CREATE INDEX task_execs_tenant_id_created_at_idx ON task_execs (tenant_id, created_at);
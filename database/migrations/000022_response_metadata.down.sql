@@ -0,0 +1,3 @@
+-- Bodies recorded as text are not necessarily JSON, they are dropped
+UPDATE task_execs
+SET response = NULL;
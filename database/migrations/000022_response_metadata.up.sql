@@ -0,0 +1,9 @@
+-- Responses are recorded with their metadata, the bodies
+-- recorded so far are kept as text
+UPDATE task_execs
+SET response = NULL
+WHERE response = 'null'::jsonb;
+
+UPDATE task_execs
+SET response = jsonb_build_object('body', response::text)
+WHERE response IS NOT NULL;
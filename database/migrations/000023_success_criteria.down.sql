@@ -0,0 +1,3 @@
+ALTER TABLE task_execs DROP COLUMN failed_assertion;
+
+ALTER TABLE tasks DROP COLUMN success_criteria;
@@ -0,0 +1,5 @@
+-- Tasks may define the rules a response must meet to be successful,
+-- executions record the rule a response did not meet
+ALTER TABLE tasks ADD COLUMN success_criteria JSONB;
+
+ALTER TABLE task_execs ADD COLUMN failed_assertion JSONB;
@@ -0,0 +1,6 @@
+DELETE FROM tasks
+WHERE type <> 'http';
+
+ALTER TABLE tasks DROP COLUMN config;
+
+ALTER TABLE tasks DROP COLUMN type;
@@ -0,0 +1,5 @@
+-- Tasks run an http request, a shell command or a sql statement, the
+-- config of shell and sql tasks is kept in config
+ALTER TABLE tasks ADD COLUMN type TEXT NOT NULL DEFAULT 'http';
+
+ALTER TABLE tasks ADD COLUMN config JSONB;
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestDSNEnv names the environment variable holding the connection
+// string of the database used by tests, they are skipped without it
+const TestDSNEnv = "TICKER_TEST_DSN"
+
+// NewTestDB returns a connection to a schema of its own in the test
+// database, migrated to the latest version and dropped once t is done
+func NewTestDB(t testing.TB) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv(TestDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", TestDSNEnv)
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("generate schema name: %v", err)
+	}
+	schema := "test_" + hex.EncodeToString(b)
+
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`); err != nil {
+			t.Errorf("drop schema: %v", err)
+		}
+	})
+
+	db, err := sql.Open("postgres", withSearchPath(dsn, schema))
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// migrations are found next to this file whatever the package under test
+	_, file, _, _ := runtime.Caller(0)
+	source := "file://" + filepath.Join(filepath.Dir(file), "migrations")
+	if err := runMigrations(db, source); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+
+	return db
+}
+
+// withSearchPath sets the search_path run-time parameter of a url or
+// key=value connection string
+func withSearchPath(dsn, schema string) string {
+	if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		return dsn + " search_path=" + schema
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return dsn
+	}
+
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
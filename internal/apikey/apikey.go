@@ -0,0 +1,214 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/stuckinforloop/ticker/internal/tenant"
+	"go.uber.org/zap"
+)
+
+type Scope string
+
+const (
+	ScopeRead  Scope = "read"  // GET requests only
+	ScopeWrite Scope = "write" // every request
+)
+
+// keyPrefix starts every key so that leaked keys are easy to spot
+const keyPrefix = "tk_"
+
+// lastUsedInterval is the number of seconds between two
+// updates of last_used_at, not every request writes it
+const lastUsedInterval int64 = 60
+
+// APIKey grants access to the HTTP API. Only the sha256 hash of
+// the key is stored, the key itself is shown once on creation.
+type APIKey struct {
+	ID         string `json:"id"`
+	TenantID   string `json:"tenant_id"`
+	Name       string `json:"name"`
+	Prefix     string `json:"prefix"`
+	Scope      Scope  `json:"scope"`
+	ExpiresAt  *int64 `json:"expires_at"`
+	LastUsedAt *int64 `json:"last_used_at"`
+	RevokedAt  *int64 `json:"revoked_at"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+// Allows reports whether the key may be used for a request of the scope
+func (k *APIKey) Allows(scope Scope) bool {
+	return k.Scope == ScopeWrite || scope == ScopeRead
+}
+
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateKey stores a new key of the tenant and returns it along
+// with the key itself
+func (dao *APIKeyDAO) CreateKey(
+	ctx context.Context, tenantID, name string, scope Scope, expiresAt *int64,
+) (*APIKey, string, error) {
+	switch scope {
+	case ScopeRead, ScopeWrite:
+	default:
+		return nil, "", fmt.Errorf("invalid scope: %s", scope)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("generate key: %w", err)
+	}
+	key := keyPrefix + hex.EncodeToString(secret)
+
+	k := &APIKey{
+		ID:        dao.ULIDSource.New(uint64(dao.TimeNow())),
+		TenantID:  tenantID,
+		Name:      name,
+		Prefix:    key[:len(keyPrefix)+8],
+		Scope:     scope,
+		ExpiresAt: expiresAt,
+		CreatedAt: dao.TimeNow(),
+	}
+
+	db := dao.RW()
+	query := `
+		INSERT INTO api_keys (
+			id, tenant_id, name, prefix, key_hash,
+			scope, expires_at, created_at
+		) Values (
+			$1, $2, $3, $4, $5,
+			$6, $7, $8
+		)
+	`
+	if _, err := db.ExecContext(ctx, query,
+		k.ID, k.TenantID, k.Name, k.Prefix, hash(key),
+		k.Scope, k.ExpiresAt, k.CreatedAt,
+	); err != nil {
+		return nil, "", fmt.Errorf("create api key: %w", err)
+	}
+
+	return k, key, nil
+}
+
+// Authenticate returns the key matching the given key, or nil if it
+// does not exist, has expired or has been revoked, or if its tenant
+// has expired
+func (dao *APIKeyDAO) Authenticate(ctx context.Context, key string) (*APIKey, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return nil, nil
+	}
+
+	now := dao.TimeNow()
+
+	db := dao.RO()
+	query := `
+		SELECT
+			k.id, k.tenant_id, k.name, k.prefix, k.scope,
+			k.expires_at, k.last_used_at, k.revoked_at, k.created_at,
+			t.status
+		FROM api_keys k
+		JOIN tenants t ON t.id = k.tenant_id
+		WHERE k.key_hash = $1
+	`
+	k := APIKey{}
+	var tenantStatus tenant.Status
+	if err := db.QueryRowContext(ctx, query, hash(key)).Scan(
+		&k.ID, &k.TenantID, &k.Name, &k.Prefix, &k.Scope,
+		&k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt,
+		&tenantStatus,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("get api key: %w", err)
+	}
+
+	if k.RevokedAt != nil || (k.ExpiresAt != nil && *k.ExpiresAt <= now) ||
+		tenantStatus == tenant.StatusExpired {
+		return nil, nil
+	}
+
+	if k.LastUsedAt == nil || *k.LastUsedAt <= now-lastUsedInterval {
+		query := `
+			UPDATE api_keys
+			SET last_used_at = $1
+			WHERE id = $2
+		`
+		// last_used_at is informational, it does not fail the request
+		if _, err := dao.RW().ExecContext(ctx, query, now, k.ID); err != nil {
+			dao.Logger.Warn("update api key last_used_at",
+				zap.Error(err),
+				zap.String("api_key_id", k.ID))
+		} else {
+			k.LastUsedAt = &now
+		}
+	}
+
+	return &k, nil
+}
+
+// RevokeKey revokes the key and reports whether an active key was revoked
+func (dao *APIKeyDAO) RevokeKey(ctx context.Context, id string) (bool, error) {
+	db := dao.RW()
+	query := `
+		UPDATE api_keys
+		SET revoked_at = $1
+		WHERE id = $2 AND revoked_at IS NULL
+	`
+	res, err := db.ExecContext(ctx, query, dao.TimeNow(), id)
+	if err != nil {
+		return false, fmt.Errorf("revoke api key: %w", err)
+	}
+
+	revoked, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("revoke api key: %w", err)
+	}
+
+	return revoked > 0, nil
+}
+
+func (dao *APIKeyDAO) ListKeys(ctx context.Context) ([]APIKey, error) {
+	db := dao.RO()
+	query := `
+		SELECT
+			id, tenant_id, name, prefix, scope,
+			expires_at, last_used_at, revoked_at, created_at
+		FROM api_keys
+		ORDER BY id
+	`
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		k := APIKey{}
+		if err := rows.Scan(
+			&k.ID, &k.TenantID, &k.Name, &k.Prefix, &k.Scope,
+			&k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		keys = append(keys, k)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration: %w", err)
+	}
+
+	return keys, nil
+}
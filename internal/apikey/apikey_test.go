@@ -0,0 +1,21 @@
+package apikey
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllows(t *testing.T) {
+	t.Run("read-key", func(t *testing.T) {
+		k := &APIKey{Scope: ScopeRead}
+		assert.True(t, k.Allows(ScopeRead))
+		assert.False(t, k.Allows(ScopeWrite))
+	})
+
+	t.Run("write-key", func(t *testing.T) {
+		k := &APIKey{Scope: ScopeWrite}
+		assert.True(t, k.Allows(ScopeRead))
+		assert.True(t, k.Allows(ScopeWrite))
+	})
+}
@@ -0,0 +1,13 @@
+package apikey
+
+import "github.com/stuckinforloop/ticker/internal/dao"
+
+type APIKeyDAO struct {
+	*dao.DAO
+}
+
+func NewAPIKeyDAO(dao *dao.DAO) *APIKeyDAO {
+	return &APIKeyDAO{
+		dao,
+	}
+}
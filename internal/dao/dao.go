@@ -1,6 +1,7 @@
 package dao
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"math/rand"
@@ -24,18 +25,12 @@ type DAO struct {
 }
 
 func New(opts ...Option) (*DAO, error) {
-	logger, err := logger.New()
-	if err != nil {
-		return nil, fmt.Errorf("setup logger: %w", err)
-	}
-
 	source := &ulid.Source{Rand: rand.New(rand.NewSource(time.Now().Unix()))}
 	timeNow := func() int64 {
 		return time.Now().Unix()
 	}
 
 	dao := DAO{
-		Logger:     logger,
 		ULIDSource: source,
 		TimeNow:    timeNow,
 	}
@@ -44,6 +39,16 @@ func New(opts ...Option) (*DAO, error) {
 		opt(&dao)
 	}
 
+	// the logger depends on the environment, only set it up when
+	// none was given
+	if dao.Logger == nil {
+		logger, err := logger.New()
+		if err != nil {
+			return nil, fmt.Errorf("setup logger: %w", err)
+		}
+		dao.Logger = logger
+	}
+
 	return &dao, nil
 }
 
@@ -98,3 +103,23 @@ func (dao *DAO) RO() *sql.DB {
 func (dao *DAO) RW() *sql.DB {
 	return dao.rw
 }
+
+// WithTx runs fn in a read-write transaction which is committed
+// if fn succeeds and rolled back otherwise
+func (dao *DAO) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := dao.rw.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
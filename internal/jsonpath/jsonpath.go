@@ -0,0 +1,109 @@
+// Package jsonpath implements the subset of JSONPath used by success
+// criteria: a path starts at the root "$" and selects object members
+// with .name or ['name'] and array elements with [index], negative
+// indexes counting from the end.
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Path is a parsed JSONPath, a list of object keys (string)
+// and array indexes (int)
+type Path []any
+
+var ErrInvalid = errors.New("invalid json path")
+
+func Parse(expr string) (Path, error) {
+	rest, ok := strings.CutPrefix(expr, "$")
+	if !ok {
+		return nil, fmt.Errorf("%w %q: must start with $", ErrInvalid, expr)
+	}
+
+	path := Path{}
+	for rest != "" {
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+
+			name := rest[1 : end+1]
+			if name == "" {
+				return nil, fmt.Errorf("%w %q: empty member name", ErrInvalid, expr)
+			}
+
+			path = append(path, name)
+			rest = rest[end+1:]
+
+		case strings.HasPrefix(rest, "['"), strings.HasPrefix(rest, `["`):
+			quote := rest[1]
+			end := strings.IndexByte(rest[2:], quote)
+			if end < 0 || !strings.HasPrefix(rest[end+3:], "]") {
+				return nil, fmt.Errorf("%w %q: unterminated member name", ErrInvalid, expr)
+			}
+
+			path = append(path, rest[2:end+2])
+			rest = rest[end+4:]
+
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%w %q: unterminated index", ErrInvalid, expr)
+			}
+
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("%w %q: invalid index %s", ErrInvalid, expr, rest[1:end])
+			}
+
+			path = append(path, index)
+			rest = rest[end+1:]
+
+		default:
+			return nil, fmt.Errorf("%w %q: unexpected %q", ErrInvalid, expr, rest[0])
+		}
+	}
+
+	return path, nil
+}
+
+// Lookup returns the value at the path in v, a value decoded by
+// encoding/json, and whether it exists
+func (p Path) Lookup(v any) (any, bool) {
+	for _, step := range p {
+		switch step := step.(type) {
+		case string:
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+
+			if v, ok = obj[step]; !ok {
+				return nil, false
+			}
+
+		case int:
+			arr, ok := v.([]any)
+			if !ok {
+				return nil, false
+			}
+
+			if step < 0 {
+				step += len(arr)
+			}
+
+			if step < 0 || step >= len(arr) {
+				return nil, false
+			}
+
+			v = arr[step]
+		}
+	}
+
+	return v, true
+}
@@ -0,0 +1,52 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for expr, want := range map[string]Path{
+		"$":                  {},
+		"$.status":           {"status"},
+		"$.data.items[0].id": {"data", "items", 0, "id"},
+		"$['a.b'][-1]":       {"a.b", -1},
+		`$["x"].y`:           {"x", "y"},
+	} {
+		path, err := Parse(expr)
+		assert.NoError(t, err, expr)
+		assert.Equal(t, want, path, expr)
+	}
+
+	for _, expr := range []string{"", "status", "$.", "$..a", "$[a]", "$['a'", "$[0", "$a"} {
+		_, err := Parse(expr)
+		assert.ErrorIs(t, err, ErrInvalid, expr)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	var doc any
+	err := json.Unmarshal([]byte(`{"data": {"items": [{"id": 1}, {"id": 2}]}, "ok": true}`), &doc)
+	assert.NoError(t, err)
+
+	lookup := func(expr string) (any, bool) {
+		path, err := Parse(expr)
+		assert.NoError(t, err)
+		return path.Lookup(doc)
+	}
+
+	v, ok := lookup("$.ok")
+	assert.True(t, ok)
+	assert.Equal(t, true, v)
+
+	v, ok = lookup("$.data.items[-1].id")
+	assert.True(t, ok)
+	assert.Equal(t, 2.0, v)
+
+	for _, expr := range []string{"$.missing", "$.data.items[2]", "$.ok.nested", "$.data[0]"} {
+		_, ok := lookup(expr)
+		assert.False(t, ok, expr)
+	}
+}
@@ -0,0 +1,13 @@
+package lease
+
+import "github.com/stuckinforloop/ticker/internal/dao"
+
+type LeaseDAO struct {
+	*dao.DAO
+}
+
+func NewLeaseDAO(dao *dao.DAO) *LeaseDAO {
+	return &LeaseDAO{
+		dao,
+	}
+}
@@ -0,0 +1,95 @@
+package lease
+
+import (
+	"context"
+	"fmt"
+)
+
+// Instance is a running scheduler process
+type Instance struct {
+	ID          string `json:"id"`
+	StartedAt   int64  `json:"started_at"`
+	HeartbeatAt int64  `json:"heartbeat_at"`
+}
+
+// Heartbeat registers the instance or refreshes its heartbeat, using
+// the clock of the database like leases do
+func (dao *LeaseDAO) Heartbeat(ctx context.Context, id string) error {
+	db := dao.RW()
+	query := `
+		INSERT INTO scheduler_instances (
+			id, started_at, heartbeat_at
+		) VALUES (
+			$1, EXTRACT(EPOCH FROM now())::BIGINT, EXTRACT(EPOCH FROM now())::BIGINT
+		)
+		ON CONFLICT (id) DO UPDATE
+		SET heartbeat_at = EXCLUDED.heartbeat_at
+	`
+	if _, err := db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("heartbeat: %w", err)
+	}
+
+	return nil
+}
+
+// ListInstances returns the instances with a heartbeat within the last
+// maxAge seconds, or all of them if maxAge is 0, ordered by id
+func (dao *LeaseDAO) ListInstances(ctx context.Context, maxAge int64) ([]Instance, error) {
+	db := dao.RO()
+	query := `
+		SELECT id, started_at, heartbeat_at
+		FROM scheduler_instances
+		WHERE $1 = 0 OR heartbeat_at > EXTRACT(EPOCH FROM now())::BIGINT - $1
+		ORDER BY id
+	`
+	rows, err := db.QueryContext(ctx, query, maxAge)
+	if err != nil {
+		return nil, fmt.Errorf("list instances: %w", err)
+	}
+	defer rows.Close()
+
+	instances := []Instance{}
+	for rows.Next() {
+		i := Instance{}
+		if err := rows.Scan(&i.ID, &i.StartedAt, &i.HeartbeatAt); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		instances = append(instances, i)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration: %w", err)
+	}
+
+	return instances, nil
+}
+
+// DeleteInstance unregisters the instance
+func (dao *LeaseDAO) DeleteInstance(ctx context.Context, id string) error {
+	db := dao.RW()
+	query := `
+		DELETE FROM scheduler_instances
+		WHERE id = $1
+	`
+	if _, err := db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("delete instance: %w", err)
+	}
+
+	return nil
+}
+
+// PruneInstances removes the instances whose last heartbeat is more
+// than maxAge seconds old
+func (dao *LeaseDAO) PruneInstances(ctx context.Context, maxAge int64) error {
+	db := dao.RW()
+	query := `
+		DELETE FROM scheduler_instances
+		WHERE heartbeat_at < EXTRACT(EPOCH FROM now())::BIGINT - $1
+	`
+	if _, err := db.ExecContext(ctx, query, maxAge); err != nil {
+		return fmt.Errorf("prune instances: %w", err)
+	}
+
+	return nil
+}
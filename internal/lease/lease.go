@@ -0,0 +1,133 @@
+package lease
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Lease is a named lock held by a single holder until it expires
+type Lease struct {
+	Name       string `json:"name"`
+	Holder     string `json:"holder"`
+	AcquiredAt int64  `json:"acquired_at"`
+	RenewedAt  int64  `json:"renewed_at"`
+	ExpiresAt  int64  `json:"expires_at"`
+}
+
+// Acquire takes the lease for holder if it is free or expired, or renews
+// it if holder already has it. It returns the current lease, which belongs
+// to another holder if it could not be acquired.
+//
+// Lease times come from the clock of the database so that skew between
+// scheduler hosts does not let two of them hold the lease at once.
+func (dao *LeaseDAO) Acquire(ctx context.Context, name, holder string, ttl int64) (*Lease, error) {
+	db := dao.RW()
+	query := `
+		INSERT INTO scheduler_leases (
+			name, holder, acquired_at, renewed_at, expires_at
+		) VALUES (
+			$1, $2,
+			EXTRACT(EPOCH FROM now())::BIGINT,
+			EXTRACT(EPOCH FROM now())::BIGINT,
+			EXTRACT(EPOCH FROM now())::BIGINT + $3
+		)
+		ON CONFLICT (name) DO UPDATE
+		SET
+			holder = EXCLUDED.holder,
+			acquired_at = CASE
+				WHEN scheduler_leases.holder = EXCLUDED.holder
+				THEN scheduler_leases.acquired_at
+				ELSE EXCLUDED.acquired_at
+			END,
+			renewed_at = EXCLUDED.renewed_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE scheduler_leases.holder = EXCLUDED.holder
+			OR scheduler_leases.expires_at <= EXCLUDED.renewed_at
+		RETURNING name, holder, acquired_at, renewed_at, expires_at
+	`
+	l := Lease{}
+	if err := db.QueryRowContext(ctx, query, name, holder, ttl).Scan(
+		&l.Name, &l.Holder, &l.AcquiredAt, &l.RenewedAt, &l.ExpiresAt,
+	); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("acquire lease: %w", err)
+		}
+
+		// held by someone else
+		return dao.GetLease(ctx, name)
+	}
+
+	return &l, nil
+}
+
+// Release gives up the lease if holder has it
+func (dao *LeaseDAO) Release(ctx context.Context, name, holder string) error {
+	db := dao.RW()
+	query := `
+		DELETE FROM scheduler_leases
+		WHERE name = $1 AND holder = $2
+	`
+	if _, err := db.ExecContext(ctx, query, name, holder); err != nil {
+		return fmt.Errorf("release lease: %w", err)
+	}
+
+	return nil
+}
+
+// GetLease returns the lease, or nil if nobody has taken it yet
+func (dao *LeaseDAO) GetLease(ctx context.Context, name string) (*Lease, error) {
+	db := dao.RO()
+	query := `
+		SELECT name, holder, acquired_at, renewed_at, expires_at
+		FROM scheduler_leases
+		WHERE name = $1
+	`
+	l := Lease{}
+	if err := db.QueryRowContext(ctx, query, name).Scan(
+		&l.Name, &l.Holder, &l.AcquiredAt, &l.RenewedAt, &l.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("get lease: %w", err)
+	}
+
+	return &l, nil
+}
+
+// ListLeases returns the leases whose name starts with prefix
+func (dao *LeaseDAO) ListLeases(ctx context.Context, prefix string) ([]Lease, error) {
+	db := dao.RO()
+	query := `
+		SELECT name, holder, acquired_at, renewed_at, expires_at
+		FROM scheduler_leases
+		WHERE starts_with(name, $1)
+		ORDER BY name
+	`
+	rows, err := db.QueryContext(ctx, query, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("list leases: %w", err)
+	}
+	defer rows.Close()
+
+	leases := []Lease{}
+	for rows.Next() {
+		l := Lease{}
+		if err := rows.Scan(
+			&l.Name, &l.Holder, &l.AcquiredAt, &l.RenewedAt, &l.ExpiresAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		leases = append(leases, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration: %w", err)
+	}
+
+	return leases, nil
+}
@@ -0,0 +1,66 @@
+package lease
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stuckinforloop/ticker/database"
+	"github.com/stuckinforloop/ticker/internal/dao"
+)
+
+func TestAcquire(t *testing.T) {
+	db := database.NewTestDB(t)
+
+	// hosts with clocks far apart from each other and from the database
+	newLeaseDAO := func(now int64) *LeaseDAO {
+		d, err := dao.NewTestDAO(
+			dao.WithDB(db, db),
+			dao.WithTimeNow(func() int64 { return now }),
+		)
+		assert.NoError(t, err)
+
+		return NewLeaseDAO(d)
+	}
+	behind := newLeaseDAO(time.Now().Unix() - 3600)
+	ahead := newLeaseDAO(time.Now().Unix() + 3600)
+
+	t.Run("database-clock", func(t *testing.T) {
+		ctx := context.Background()
+
+		l, err := behind.Acquire(ctx, "database-clock", "behind", 15)
+		assert.NoError(t, err)
+		assert.Equal(t, "behind", l.Holder)
+		assert.InDelta(t, time.Now().Unix(), l.AcquiredAt, 5)
+		assert.Equal(t, l.AcquiredAt+15, l.ExpiresAt)
+
+		// a host whose clock is ahead does not see the lease as expired
+		l, err = ahead.Acquire(ctx, "database-clock", "ahead", 15)
+		assert.NoError(t, err)
+		assert.Equal(t, "behind", l.Holder)
+	})
+
+	t.Run("renew", func(t *testing.T) {
+		ctx := context.Background()
+
+		first, err := behind.Acquire(ctx, "renew", "behind", 15)
+		assert.NoError(t, err)
+
+		l, err := behind.Acquire(ctx, "renew", "behind", 30)
+		assert.NoError(t, err)
+		assert.Equal(t, first.AcquiredAt, l.AcquiredAt)
+		assert.Equal(t, l.RenewedAt+30, l.ExpiresAt)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		ctx := context.Background()
+
+		_, err := behind.Acquire(ctx, "expired", "behind", 0)
+		assert.NoError(t, err)
+
+		l, err := ahead.Acquire(ctx, "expired", "ahead", 15)
+		assert.NoError(t, err)
+		assert.Equal(t, "ahead", l.Holder)
+	})
+}
@@ -0,0 +1,151 @@
+package notification
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// emailTimeout bounds the whole SMTP exchange when ctx has no deadline
+const emailTimeout = 30 * time.Second
+
+type EmailConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+func (c EmailConfig) Validate() error {
+	if c.Host == "" {
+		return errors.New("host is required")
+	}
+
+	if c.Port <= 0 {
+		return errors.New("port is required")
+	}
+
+	if c.From == "" {
+		return errors.New("from is required")
+	}
+
+	if len(c.To) == 0 {
+		return errors.New("to is required")
+	}
+
+	return nil
+}
+
+// Email sends the event as a plain text mail over SMTP
+type Email struct {
+	cfg EmailConfig
+}
+
+func NewEmail(cfg EmailConfig) *Email {
+	return &Email{cfg}
+}
+
+func (m *Email) Notify(ctx context.Context, e Event) error {
+	msg := strings.Join([]string{
+		"From: " + m.cfg.From,
+		"To: " + strings.Join(m.cfg.To, ", "),
+		"Subject: " + encodeHeader(e.Subject()),
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		e.Text(),
+	}, "\r\n")
+
+	if err := m.send(ctx, []byte(msg)); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+
+	return nil
+}
+
+// encodeHeader keeps a header value on a single line, task names are
+// user input and could otherwise inject headers
+func encodeHeader(v string) string {
+	v = strings.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' {
+			return ' '
+		}
+		return r
+	}, v)
+
+	return mime.QEncoding.Encode("UTF-8", v)
+}
+
+// send is smtp.SendMail with a deadline on the connection
+func (m *Email) send(ctx context.Context, msg []byte) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, emailTimeout)
+		defer cancel()
+	}
+	deadline, _ := ctx.Deadline()
+
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.cfg.Host}); err != nil {
+			return err
+		}
+	}
+
+	if m.cfg.Username != "" {
+		auth := smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(m.cfg.From); err != nil {
+		return err
+	}
+
+	for _, to := range m.cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
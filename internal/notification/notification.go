@@ -0,0 +1,117 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+type EventType string
+
+const (
+	EventFailed    EventType = "failed"
+	EventRecovered EventType = "recovered"
+	EventDisabled  EventType = "disabled"
+)
+
+const (
+	ChannelWebhook = "webhook"
+	ChannelSlack   = "slack"
+	ChannelEmail   = "email"
+)
+
+type Event struct {
+	Type                EventType `json:"type"`
+	TaskID              string    `json:"task_id"`
+	TaskName            string    `json:"task_name"`
+	TaskExecID          string    `json:"task_exec_id"`
+	RunAt               int64     `json:"run_at"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Error               *string   `json:"error"`
+	Timestamp           int64     `json:"timestamp"`
+}
+
+// Subject is a one line summary of the event
+func (e Event) Subject() string {
+	switch e.Type {
+	case EventRecovered:
+		return fmt.Sprintf("[ticker] task %s recovered", e.TaskName)
+	case EventDisabled:
+		return fmt.Sprintf("[ticker] task %s disabled", e.TaskName)
+	default:
+		return fmt.Sprintf("[ticker] task %s failed", e.TaskName)
+	}
+}
+
+// Text is a human readable description of the event
+func (e Event) Text() string {
+	text := fmt.Sprintf("%s\ntask: %s\nexecution: %s\nrun_at: %d\nconsecutive failures: %d",
+		e.Subject(), e.TaskID, e.TaskExecID, e.RunAt, e.ConsecutiveFailures)
+	if e.Error != nil {
+		text += fmt.Sprintf("\nerror: %s", *e.Error)
+	}
+
+	return text
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, e Event) error
+}
+
+// Notifiers sends an event to every notifier it holds
+type Notifiers []Notifier
+
+func (n Notifiers) Notify(ctx context.Context, e Event) error {
+	var errs []error
+	for _, notifier := range n {
+		if err := notifier.Notify(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// New builds the notifiers enabled by notifications.channels. It
+// returns an error if a channel is unknown or not configured.
+func New() (Notifier, error) {
+	notifiers := Notifiers{}
+	for _, channel := range viper.GetStringSlice("notifications.channels") {
+		switch channel {
+		case ChannelWebhook:
+			url := viper.GetString("notifications.webhook.url")
+			if url == "" {
+				return nil, errors.New("notifications.webhook.url is required")
+			}
+
+			notifiers = append(notifiers, NewWebhook(url))
+		case ChannelSlack:
+			url := viper.GetString("notifications.slack.webhook_url")
+			if url == "" {
+				return nil, errors.New("notifications.slack.webhook_url is required")
+			}
+
+			notifiers = append(notifiers, NewSlack(url))
+		case ChannelEmail:
+			cfg := EmailConfig{
+				Host:     viper.GetString("notifications.email.host"),
+				Port:     viper.GetInt("notifications.email.port"),
+				Username: viper.GetString("notifications.email.username"),
+				Password: viper.GetString("notifications.email.password"),
+				From:     viper.GetString("notifications.email.from"),
+				To:       viper.GetStringSlice("notifications.email.to"),
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, fmt.Errorf("notifications.email: %w", err)
+			}
+
+			notifiers = append(notifiers, NewEmail(cfg))
+		default:
+			return nil, fmt.Errorf("unsupported notification channel: %s", channel)
+		}
+	}
+
+	return notifiers, nil
+}
@@ -0,0 +1,52 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	defer viper.Set("notifications", nil)
+
+	t.Run("unknown-channel", func(t *testing.T) {
+		viper.Set("notifications.channels", []string{"pager"})
+
+		_, err := New()
+		assert.ErrorContains(t, err, "pager")
+	})
+
+	t.Run("missing-config", func(t *testing.T) {
+		viper.Set("notifications.channels", []string{ChannelEmail})
+		viper.Set("notifications.email.host", "smtp.example.com")
+
+		_, err := New()
+		assert.Error(t, err)
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		viper.Set("notifications.channels", []string{ChannelWebhook})
+		viper.Set("notifications.webhook.url", "https://example.com/hook")
+
+		n, err := New()
+		assert.NoError(t, err)
+		assert.Len(t, n, 1)
+	})
+}
+
+func TestEncodeHeader(t *testing.T) {
+	t.Run("plain", func(t *testing.T) {
+		assert.Equal(t, "[ticker] task backup failed", encodeHeader("[ticker] task backup failed"))
+	})
+
+	t.Run("line-breaks", func(t *testing.T) {
+		v := encodeHeader("[ticker] task x\r\nBcc: victim@example.com failed")
+		assert.NotContains(t, v, "\r")
+		assert.NotContains(t, v, "\n")
+	})
+
+	t.Run("non-ascii", func(t *testing.T) {
+		assert.Equal(t, "=?UTF-8?q?t=C3=A2che?=", encodeHeader("tâche"))
+	})
+}
@@ -0,0 +1,28 @@
+package notification
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Slack posts the event to a Slack compatible incoming webhook
+type Slack struct {
+	webhookURL string
+	client     *http.Client
+}
+
+func NewSlack(webhookURL string) *Slack {
+	return &Slack{
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *Slack) Notify(ctx context.Context, e Event) error {
+	payload := map[string]string{
+		"text": e.Text(),
+	}
+
+	return post(ctx, s.client, s.webhookURL, payload)
+}
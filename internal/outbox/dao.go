@@ -0,0 +1,18 @@
+package outbox
+
+import (
+	"github.com/stuckinforloop/ticker/internal/dao"
+	"github.com/stuckinforloop/ticker/internal/queue"
+)
+
+type OutboxDAO struct {
+	*dao.DAO
+	Queue queue.Queue
+}
+
+func NewOutboxDAO(dao *dao.DAO, queue queue.Queue) *OutboxDAO {
+	return &OutboxDAO{
+		dao,
+		queue,
+	}
+}
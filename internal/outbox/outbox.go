@@ -0,0 +1,189 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// batchSize is the number of messages published per transaction
+	batchSize = 10
+
+	// maxBackoff caps the delay in seconds between publish attempts
+	maxBackoff int64 = 300
+)
+
+// Message is a queue message waiting to be published
+type Message struct {
+	ID          int64
+	QueueName   string
+	Message     string
+	DedupeID    *string
+	GroupID     *string
+	Delay       int64
+	Attempts    int
+	LastError   *string
+	AvailableAt int64
+	CreatedAt   int64
+}
+
+// Add stores the message as part of tx, it is published by the relay
+// once tx commits. A delayed message is held in the outbox until it is
+// due, FIFO queues of SQS do not support per-message delays.
+func (dao *OutboxDAO) Add(ctx context.Context, tx *sql.Tx, m *Message) error {
+	m.CreatedAt = dao.TimeNow()
+	m.AvailableAt = m.CreatedAt + m.Delay
+
+	query := `
+		INSERT INTO outbox (
+			queue_name, message, dedupe_id, group_id,
+			delay, available_at, created_at
+		) VALUES (
+			$1, $2, $3, $4,
+			$5, $6, $7
+		)
+		RETURNING id
+	`
+	if err := tx.QueryRowContext(ctx, query,
+		m.QueueName, m.Message, m.DedupeID, m.GroupID,
+		m.Delay, m.AvailableAt, m.CreatedAt,
+	).Scan(&m.ID); err != nil {
+		return fmt.Errorf("add outbox message: %w", err)
+	}
+
+	return nil
+}
+
+// Relay publishes outbox messages to the queue broker until ctx is done.
+// Failed publishes are retried with exponential backoff, so every message
+// is delivered at least once.
+func (dao *OutboxDAO) Relay(ctx context.Context) error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			// drain the outbox before waiting for the next tick
+			for {
+				published, err := dao.relayBatch(ctx)
+				if err != nil {
+					dao.Logger.Error("relay outbox", zap.Error(err))
+					break
+				}
+
+				if published < batchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// relayBatch publishes a batch of available messages and returns how
+// many messages were picked up
+func (dao *OutboxDAO) relayBatch(ctx context.Context) (int, error) {
+	now := dao.TimeNow()
+
+	tx, err := dao.RW().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT
+			id, queue_name, message, dedupe_id,
+			group_id, delay, attempts
+		FROM outbox
+		WHERE available_at <= $1
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.QueryContext(ctx, query, now, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("select outbox: %w", err)
+	}
+
+	messages := []Message{}
+	for rows.Next() {
+		m := Message{}
+		if err := rows.Scan(
+			&m.ID, &m.QueueName, &m.Message, &m.DedupeID,
+			&m.GroupID, &m.Delay, &m.Attempts,
+		); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan row: %w", err)
+		}
+
+		messages = append(messages, m)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("rows iteration: %w", err)
+	}
+
+	for _, m := range messages {
+		if err := dao.publish(ctx, tx, &m, now); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit tx: %w", err)
+	}
+
+	return len(messages), nil
+}
+
+// publish sends the message to the broker and removes it from the
+// outbox, or schedules another attempt if the broker is unavailable.
+// The delay of the message has elapsed by the time it is published.
+func (dao *OutboxDAO) publish(ctx context.Context, tx *sql.Tx, m *Message, now int64) error {
+	messageID, err := dao.Queue.Enqueue(ctx, m.QueueName, m.Message, m.DedupeID, m.GroupID, 0)
+	if err != nil {
+		backoff := min(int64(1)<<min(m.Attempts, 16), maxBackoff)
+		dao.Logger.Warn("Publish outbox message failed",
+			zap.Error(err),
+			zap.Int64("outbox_id", m.ID),
+			zap.Int("attempts", m.Attempts+1),
+			zap.Int64("backoff", backoff))
+
+		query := `
+			UPDATE outbox
+			SET
+				attempts = attempts + 1,
+				last_error = $1,
+				available_at = $2
+			WHERE id = $3
+		`
+		if _, err := tx.ExecContext(ctx, query, err.Error(), now+backoff, m.ID); err != nil {
+			return fmt.Errorf("update outbox message: %w", err)
+		}
+
+		return nil
+	}
+
+	query := `
+		DELETE FROM outbox
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, query, m.ID); err != nil {
+		return fmt.Errorf("delete outbox message: %w", err)
+	}
+
+	dao.Logger.Info("Publish outbox message success",
+		zap.Int64("outbox_id", m.ID),
+		zap.String("queue_name", m.QueueName),
+		zap.String("message_id", messageID))
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stuckinforloop/ticker/database"
+	"github.com/stuckinforloop/ticker/internal/dao"
+	"github.com/stuckinforloop/ticker/internal/queue/broker"
+)
+
+func TestRelay(t *testing.T) {
+	db := database.NewTestDB(t)
+
+	t.Run("delay", func(t *testing.T) {
+		ctx := context.Background()
+		now := int64(1700000000)
+		d, err := dao.NewTestDAO(
+			dao.WithDB(db, db),
+			dao.WithTimeNow(func() int64 { return now }),
+		)
+		assert.NoError(t, err)
+
+		q := broker.NewMemory()
+		outboxDAO := NewOutboxDAO(d, q)
+
+		err = d.WithTx(ctx, func(tx *sql.Tx) error {
+			return outboxDAO.Add(ctx, tx, &Message{
+				QueueName: "task-executor-1.fifo",
+				Message:   "retry",
+				GroupID:   typePtr("exec-1"),
+				Delay:     30,
+			})
+		})
+		assert.NoError(t, err)
+
+		dequeue := func() string {
+			ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			defer cancel()
+
+			_, msg, err := q.Dequeue(ctx, "task-executor-1.fifo", 30)
+			assert.NoError(t, err)
+			return msg
+		}
+
+		// the message is not handed to the broker before it is due
+		now += 29
+		published, err := outboxDAO.relayBatch(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, published)
+		assert.Empty(t, dequeue())
+
+		now++
+		published, err = outboxDAO.relayBatch(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, published)
+		assert.Equal(t, "retry", dequeue())
+	})
+}
+
+func typePtr[T any](t T) *T {
+	return &t
+}
@@ -0,0 +1,185 @@
+package broker
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process queue broker. It follows the same delivery
+// semantics as the Postgres broker (FIFO per group, dedupe window,
+// visibility timeout) but only works within a single process.
+type Memory struct {
+	mu     sync.Mutex
+	seq    int64
+	queues map[string]*memoryQueue
+
+	// wake is closed and replaced whenever a message is enqueued
+	wake chan struct{}
+}
+
+type memoryQueue struct {
+	messages []*memoryMessage
+	dedupes  map[string]memoryDedupe
+}
+
+type memoryMessage struct {
+	id            string
+	body          string
+	groupID       *string
+	receiptHandle string
+	visibleAt     time.Time
+}
+
+type memoryDedupe struct {
+	messageID string
+	expiresAt time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		queues: map[string]*memoryQueue{},
+		wake:   make(chan struct{}),
+	}
+}
+
+func (q *Memory) Enqueue(
+	ctx context.Context, queueName string, message string,
+	dedupeID *string, groupID *string, delay int64,
+) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	mq := q.queue(queueName)
+
+	if dedupeID != nil {
+		if d, ok := mq.dedupes[*dedupeID]; ok && d.expiresAt.After(now) {
+			return d.messageID, nil
+		}
+	}
+
+	q.seq++
+	msg := &memoryMessage{
+		id:        strconv.FormatInt(q.seq, 10),
+		body:      message,
+		groupID:   groupID,
+		visibleAt: now.Add(time.Duration(delay) * time.Second),
+	}
+	mq.messages = append(mq.messages, msg)
+
+	if dedupeID != nil {
+		mq.dedupes[*dedupeID] = memoryDedupe{
+			messageID: msg.id,
+			expiresAt: now.Add(dedupeWindow),
+		}
+	}
+
+	close(q.wake)
+	q.wake = make(chan struct{})
+
+	return msg.id, nil
+}
+
+func (q *Memory) Dequeue(
+	ctx context.Context, queueName string, visibilityTimeout int64) (string, string, error,
+) {
+	deadline := time.NewTimer(waitTime)
+	defer deadline.Stop()
+
+	for {
+		receiptHandle, message, wake, err := q.receive(queueName, visibilityTimeout)
+		if err != nil {
+			return "", "", err
+		}
+
+		if receiptHandle != "" {
+			return receiptHandle, message, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", "", nil
+		case <-deadline.C:
+			return "", "", nil
+		case <-wake:
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func (q *Memory) Acknowledge(
+	ctx context.Context, id string, queueName string,
+) error {
+	if id == "" {
+		return nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	mq := q.queue(queueName)
+	for i, msg := range mq.messages {
+		if msg.receiptHandle == id {
+			mq.messages = append(mq.messages[:i], mq.messages[i+1:]...)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// receive claims the oldest visible message that is at the head of its
+// group. It also returns the wake channel to wait on if nothing was found.
+func (q *Memory) receive(
+	queueName string, visibilityTimeout int64,
+) (string, string, <-chan struct{}, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	mq := q.queue(queueName)
+
+	for id, d := range mq.dedupes {
+		if !d.expiresAt.After(now) {
+			delete(mq.dedupes, id)
+		}
+	}
+
+	blocked := map[string]bool{}
+	for _, msg := range mq.messages {
+		if msg.groupID != nil {
+			if blocked[*msg.groupID] {
+				continue
+			}
+			blocked[*msg.groupID] = true
+		}
+
+		if msg.visibleAt.After(now) {
+			continue
+		}
+
+		receiptHandle, err := newReceiptHandle()
+		if err != nil {
+			return "", "", nil, err
+		}
+
+		msg.receiptHandle = receiptHandle
+		msg.visibleAt = now.Add(time.Duration(visibilityTimeout) * time.Second)
+
+		return receiptHandle, msg.body, nil, nil
+	}
+
+	return "", "", q.wake, nil
+}
+
+func (q *Memory) queue(queueName string) *memoryQueue {
+	mq, ok := q.queues[queueName]
+	if !ok {
+		mq = &memoryQueue{dedupes: map[string]memoryDedupe{}}
+		q.queues[queueName] = mq
+	}
+
+	return mq
+}
@@ -0,0 +1,95 @@
+package broker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory(t *testing.T) {
+	queueName := "test-queue"
+	group := "group-1"
+
+	t.Run("fifo-per-group", func(t *testing.T) {
+		ctx := context.Background()
+		q := NewMemory()
+
+		_, err := q.Enqueue(ctx, queueName, "first", nil, &group, 0)
+		assert.NoError(t, err)
+		_, err = q.Enqueue(ctx, queueName, "second", nil, &group, 0)
+		assert.NoError(t, err)
+		_, err = q.Enqueue(ctx, queueName, "other", nil, nil, 0)
+		assert.NoError(t, err)
+
+		receipt, msg, err := q.Dequeue(ctx, queueName, 30)
+		assert.NoError(t, err)
+		assert.Equal(t, "first", msg)
+
+		// "second" is blocked until "first" is acknowledged
+		_, msg, err = q.Dequeue(ctx, queueName, 30)
+		assert.NoError(t, err)
+		assert.Equal(t, "other", msg)
+
+		assert.NoError(t, q.Acknowledge(ctx, receipt, queueName))
+
+		_, msg, err = q.Dequeue(ctx, queueName, 30)
+		assert.NoError(t, err)
+		assert.Equal(t, "second", msg)
+	})
+
+	t.Run("dedupe", func(t *testing.T) {
+		ctx := context.Background()
+		q := NewMemory()
+		dedupeID := "task-1"
+
+		id1, err := q.Enqueue(ctx, queueName, "first", &dedupeID, &group, 0)
+		assert.NoError(t, err)
+		id2, err := q.Enqueue(ctx, queueName, "duplicate", &dedupeID, &group, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, id1, id2)
+
+		receipt, msg, err := q.Dequeue(ctx, queueName, 30)
+		assert.NoError(t, err)
+		assert.Equal(t, "first", msg)
+		assert.NoError(t, q.Acknowledge(ctx, receipt, queueName))
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		receipt, msg, err = q.Dequeue(ctx, queueName, 30)
+		assert.NoError(t, err)
+		assert.Empty(t, receipt)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("visibility-timeout", func(t *testing.T) {
+		ctx := context.Background()
+		q := NewMemory()
+
+		_, err := q.Enqueue(ctx, queueName, "first", nil, nil, 0)
+		assert.NoError(t, err)
+
+		receipt1, msg, err := q.Dequeue(ctx, queueName, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, "first", msg)
+
+		// not acknowledged within the visibility timeout, delivered again
+		receipt2, msg, err := q.Dequeue(ctx, queueName, 30)
+		assert.NoError(t, err)
+		assert.Equal(t, "first", msg)
+		assert.NotEqual(t, receipt1, receipt2)
+
+		// stale receipt handles do not delete the message
+		assert.NoError(t, q.Acknowledge(ctx, receipt1, queueName))
+		assert.NoError(t, q.Acknowledge(ctx, receipt2, queueName))
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		receipt, _, err := q.Dequeue(ctx, queueName, 30)
+		assert.NoError(t, err)
+		assert.Empty(t, receipt)
+	})
+}
@@ -0,0 +1,227 @@
+package broker
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// dedupeWindow mirrors the deduplication interval of SQS FIFO queues
+	dedupeWindow = 5 * time.Minute
+
+	// waitTime and pollInterval emulate SQS long polling
+	waitTime     = 20 * time.Second
+	pollInterval = time.Second
+)
+
+// Postgres is a queue broker backed by the queue_messages table.
+// Messages sharing a group id are delivered one at a time in the
+// order they were enqueued, like SQS FIFO message groups.
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db}
+}
+
+func (q *Postgres) Enqueue(
+	ctx context.Context, queueName string, message string,
+	dedupeID *string, groupID *string, delay int64,
+) (string, error) {
+	now := time.Now()
+
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if dedupeID != nil {
+		query := `
+			DELETE FROM queue_dedupes
+			WHERE queue_name = $1 AND expires_at <= $2
+		`
+		if _, err := tx.ExecContext(ctx, query, queueName, now.Unix()); err != nil {
+			return "", fmt.Errorf("delete expired dedupes: %w", err)
+		}
+	}
+
+	var id int64
+	query := `
+		INSERT INTO queue_messages (
+			queue_name, body, group_id, visible_at, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5
+		)
+		RETURNING id
+	`
+	if err := tx.QueryRowContext(ctx, query,
+		queueName, message, groupID, now.Unix()+delay, now.Unix(),
+	).Scan(&id); err != nil {
+		return "", fmt.Errorf("enqueue failed: %w", err)
+	}
+
+	if dedupeID != nil {
+		query := `
+			INSERT INTO queue_dedupes (
+				queue_name, dedupe_id, message_id, expires_at
+			) VALUES (
+				$1, $2, $3, $4
+			)
+			ON CONFLICT DO NOTHING
+		`
+		res, err := tx.ExecContext(ctx, query,
+			queueName, *dedupeID, id, now.Add(dedupeWindow).Unix())
+		if err != nil {
+			return "", fmt.Errorf("insert dedupe: %w", err)
+		}
+
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			return "", fmt.Errorf("insert dedupe: %w", err)
+		}
+
+		// message was already enqueued within the dedupe window,
+		// discard this one and report the original message id
+		if inserted == 0 {
+			if err := tx.Rollback(); err != nil {
+				return "", fmt.Errorf("rollback tx: %w", err)
+			}
+
+			return q.findDedupe(ctx, queueName, *dedupeID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("commit tx: %w", err)
+	}
+
+	return strconv.FormatInt(id, 10), nil
+}
+
+func (q *Postgres) Dequeue(
+	ctx context.Context, queueName string, visibilityTimeout int64) (string, string, error,
+) {
+	deadline := time.Now().Add(waitTime)
+	for {
+		receiptHandle, message, err := q.receive(ctx, queueName, visibilityTimeout)
+		if err != nil {
+			return "", "", fmt.Errorf("dequeue failed: %w", err)
+		}
+
+		if receiptHandle != "" || time.Now().After(deadline) {
+			return receiptHandle, message, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", "", nil
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func (q *Postgres) Acknowledge(
+	ctx context.Context, id string, queueName string,
+) error {
+	query := `
+		DELETE FROM queue_messages
+		WHERE queue_name = $1 AND receipt_handle = $2
+	`
+	if _, err := q.db.ExecContext(ctx, query, queueName, id); err != nil {
+		return fmt.Errorf("acknowledge failed: %w", err)
+	}
+
+	return nil
+}
+
+// receive claims the oldest visible message that is at the head of its
+// group and hides it for visibilityTimeout seconds.
+func (q *Postgres) receive(
+	ctx context.Context, queueName string, visibilityTimeout int64,
+) (string, string, error) {
+	now := time.Now().Unix()
+
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int64
+	var message string
+	query := `
+		SELECT m.id, m.body
+		FROM queue_messages m
+		WHERE m.queue_name = $1
+			AND m.visible_at <= $2
+			AND NOT EXISTS (
+				SELECT 1 FROM queue_messages g
+				WHERE g.queue_name = m.queue_name
+					AND g.group_id = m.group_id
+					AND g.id < m.id
+			)
+		ORDER BY m.id
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`
+	if err := tx.QueryRowContext(ctx, query, queueName, now).Scan(&id, &message); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", nil
+		}
+
+		return "", "", fmt.Errorf("select message: %w", err)
+	}
+
+	receiptHandle, err := newReceiptHandle()
+	if err != nil {
+		return "", "", err
+	}
+
+	query = `
+		UPDATE queue_messages
+		SET
+			receipt_handle = $1,
+			receive_count = receive_count + 1,
+			visible_at = $2
+		WHERE id = $3
+	`
+	if _, err := tx.ExecContext(ctx, query, receiptHandle, now+visibilityTimeout, id); err != nil {
+		return "", "", fmt.Errorf("update message: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", "", fmt.Errorf("commit tx: %w", err)
+	}
+
+	return receiptHandle, message, nil
+}
+
+func (q *Postgres) findDedupe(ctx context.Context, queueName, dedupeID string) (string, error) {
+	var id int64
+	query := `
+		SELECT message_id FROM queue_dedupes
+		WHERE queue_name = $1 AND dedupe_id = $2
+	`
+	if err := q.db.QueryRowContext(ctx, query, queueName, dedupeID).Scan(&id); err != nil {
+		return "", fmt.Errorf("find dedupe: %w", err)
+	}
+
+	return strconv.FormatInt(id, 10), nil
+}
+
+func newReceiptHandle() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate receipt handle: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
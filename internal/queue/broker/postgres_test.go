@@ -0,0 +1,158 @@
+package broker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stuckinforloop/ticker/database"
+)
+
+func TestPostgres(t *testing.T) {
+	db := database.NewTestDB(t)
+	group := "group-1"
+
+	// empty polls return once ctx is done instead of after waitTime
+	emptyCtx := func() (context.Context, context.CancelFunc) {
+		return context.WithTimeout(context.Background(), 500*time.Millisecond)
+	}
+
+	t.Run("fifo-per-group", func(t *testing.T) {
+		ctx := context.Background()
+		q := NewPostgres(db)
+		queueName := "fifo-per-group"
+
+		_, err := q.Enqueue(ctx, queueName, "first", nil, &group, 0)
+		assert.NoError(t, err)
+		_, err = q.Enqueue(ctx, queueName, "second", nil, &group, 0)
+		assert.NoError(t, err)
+		_, err = q.Enqueue(ctx, queueName, "other", nil, nil, 0)
+		assert.NoError(t, err)
+
+		receipt, msg, err := q.Dequeue(ctx, queueName, 30)
+		assert.NoError(t, err)
+		assert.Equal(t, "first", msg)
+
+		// "second" is blocked until "first" is acknowledged
+		_, msg, err = q.Dequeue(ctx, queueName, 30)
+		assert.NoError(t, err)
+		assert.Equal(t, "other", msg)
+
+		assert.NoError(t, q.Acknowledge(ctx, receipt, queueName))
+
+		_, msg, err = q.Dequeue(ctx, queueName, 30)
+		assert.NoError(t, err)
+		assert.Equal(t, "second", msg)
+	})
+
+	t.Run("dedupe", func(t *testing.T) {
+		ctx := context.Background()
+		q := NewPostgres(db)
+		queueName := "dedupe"
+		dedupeID := "task-1"
+
+		id1, err := q.Enqueue(ctx, queueName, "first", &dedupeID, &group, 0)
+		assert.NoError(t, err)
+		id2, err := q.Enqueue(ctx, queueName, "duplicate", &dedupeID, &group, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, id1, id2)
+
+		receipt, msg, err := q.Dequeue(ctx, queueName, 30)
+		assert.NoError(t, err)
+		assert.Equal(t, "first", msg)
+		assert.NoError(t, q.Acknowledge(ctx, receipt, queueName))
+
+		ctx, cancel := emptyCtx()
+		defer cancel()
+
+		receipt, msg, err = q.Dequeue(ctx, queueName, 30)
+		assert.NoError(t, err)
+		assert.Empty(t, receipt)
+		assert.Empty(t, msg)
+	})
+
+	t.Run("visibility-timeout", func(t *testing.T) {
+		ctx := context.Background()
+		q := NewPostgres(db)
+		queueName := "visibility-timeout"
+
+		_, err := q.Enqueue(ctx, queueName, "first", nil, nil, 0)
+		assert.NoError(t, err)
+
+		receipt1, msg, err := q.Dequeue(ctx, queueName, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, "first", msg)
+
+		// not acknowledged within the visibility timeout, delivered again
+		receipt2, msg, err := q.Dequeue(ctx, queueName, 30)
+		assert.NoError(t, err)
+		assert.Equal(t, "first", msg)
+		assert.NotEqual(t, receipt1, receipt2)
+
+		// stale receipt handles do not delete the message
+		assert.NoError(t, q.Acknowledge(ctx, receipt1, queueName))
+		assert.NoError(t, q.Acknowledge(ctx, receipt2, queueName))
+
+		ctx, cancel := emptyCtx()
+		defer cancel()
+
+		receipt, _, err := q.Dequeue(ctx, queueName, 30)
+		assert.NoError(t, err)
+		assert.Empty(t, receipt)
+	})
+
+	t.Run("delay", func(t *testing.T) {
+		ctx := context.Background()
+		q := NewPostgres(db)
+		queueName := "delay"
+
+		_, err := q.Enqueue(ctx, queueName, "later", nil, nil, 60)
+		assert.NoError(t, err)
+
+		var visibleAt int64
+		err = db.QueryRowContext(ctx,
+			`SELECT visible_at FROM queue_messages WHERE queue_name = $1`, queueName,
+		).Scan(&visibleAt)
+		assert.NoError(t, err)
+		assert.InDelta(t, time.Now().Unix()+60, visibleAt, 5)
+
+		ctx, cancel := emptyCtx()
+		defer cancel()
+
+		receipt, _, err := q.Dequeue(ctx, queueName, 30)
+		assert.NoError(t, err)
+		assert.Empty(t, receipt)
+	})
+
+	t.Run("skip-locked", func(t *testing.T) {
+		ctx := context.Background()
+		q := NewPostgres(db)
+		queueName := "skip-locked"
+
+		_, err := q.Enqueue(ctx, queueName, "locked", nil, nil, 0)
+		assert.NoError(t, err)
+		_, err = q.Enqueue(ctx, queueName, "free", nil, nil, 0)
+		assert.NoError(t, err)
+
+		// another consumer is in the middle of claiming the first message
+		tx, err := db.BeginTx(ctx, nil)
+		assert.NoError(t, err)
+		defer tx.Rollback()
+
+		var body string
+		err = tx.QueryRowContext(ctx, `
+			SELECT body FROM queue_messages
+			WHERE queue_name = $1
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE
+		`, queueName).Scan(&body)
+		assert.NoError(t, err)
+		assert.Equal(t, "locked", body)
+
+		_, msg, err := q.Dequeue(ctx, queueName, 30)
+		assert.NoError(t, err)
+		assert.Equal(t, "free", msg)
+	})
+}
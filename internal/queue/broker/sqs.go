@@ -42,14 +42,20 @@ func (q *SQS) Enqueue(
 	ctx context.Context, queueName string, message string,
 	dedupeID *string, groupID *string, delay int64,
 ) (string, error) {
-	result, err := q.client.SendMessage(
-		&sqs.SendMessageInput{
-			DelaySeconds:           aws.Int64(delay),
-			MessageBody:            aws.String(message),
-			MessageDeduplicationId: dedupeID,
-			MessageGroupId:         groupID,
-			QueueUrl:               aws.String(q.getQueueURL(queueName)),
-		})
+	input := &sqs.SendMessageInput{
+		MessageBody:            aws.String(message),
+		MessageDeduplicationId: dedupeID,
+		MessageGroupId:         groupID,
+		QueueUrl:               aws.String(q.getQueueURL(queueName)),
+	}
+
+	// FIFO queues reject per-message delays, delayed messages
+	// are held back in the outbox until they are due
+	if !strings.HasSuffix(queueName, ".fifo") {
+		input.DelaySeconds = aws.Int64(delay)
+	}
+
+	result, err := q.client.SendMessage(input)
 
 	if err != nil {
 		return "", fmt.Errorf("enqueue failed: %w", err)
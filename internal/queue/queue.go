@@ -2,12 +2,24 @@ package queue
 
 import (
 	"context"
+	"database/sql"
+	"sync"
 
 	"github.com/spf13/viper"
 	"github.com/stuckinforloop/ticker/internal/queue/broker"
 )
 
-const QueueBrokerSQS = "sqs"
+const (
+	QueueBrokerSQS      = "sqs"
+	QueueBrokerPostgres = "postgres"
+	QueueBrokerMemory   = "memory"
+)
+
+// memory is shared by every caller in the process, messages
+// are only visible to handlers running in the same process
+var memory = sync.OnceValue(func() *broker.Memory {
+	return broker.NewMemory()
+})
 
 type Queue interface {
 	Enqueue(
@@ -29,11 +41,15 @@ type Queue interface {
 		queueName string) error
 }
 
-func New() Queue {
+func New(db *sql.DB) Queue {
 	queueBroker := viper.GetString("queue_broker")
 	switch queueBroker {
 	case QueueBrokerSQS:
 		return broker.NewSQS()
+	case QueueBrokerPostgres:
+		return broker.NewPostgres(db)
+	case QueueBrokerMemory:
+		return memory()
 	default:
 		panic("unsupported queue broker")
 	}
@@ -0,0 +1,13 @@
+package quota
+
+import "github.com/stuckinforloop/ticker/internal/dao"
+
+type QuotaDAO struct {
+	*dao.DAO
+}
+
+func NewQuotaDAO(dao *dao.DAO) *QuotaDAO {
+	return &QuotaDAO{
+		dao,
+	}
+}
@@ -0,0 +1,170 @@
+package quota
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+// Quotas limit what a tenant may schedule, 0 means unlimited
+type Quotas struct {
+	MaxTasks             int `json:"max_tasks"`
+	MinInterval          int `json:"min_interval"` // seconds between two runs of a task
+	MaxExecutionsPerHour int `json:"max_executions_per_hour"`
+	MaxTimeout           int `json:"max_timeout"` // seconds
+}
+
+// Overrides replace the configured quotas of a tenant, nil
+// fields fall back to the configuration
+type Overrides struct {
+	MaxTasks             *int `json:"max_tasks"`
+	MinInterval          *int `json:"min_interval"`
+	MaxExecutionsPerHour *int `json:"max_executions_per_hour"`
+	MaxTimeout           *int `json:"max_timeout"`
+}
+
+// Defaults returns the quotas configured in the quotas section,
+// they apply to every tenant without overrides
+func Defaults() Quotas {
+	return Quotas{
+		MaxTasks:             max(viper.GetInt("quotas.max_tasks"), 0),
+		MinInterval:          max(viper.GetInt("quotas.min_interval"), 0),
+		MaxExecutionsPerHour: max(viper.GetInt("quotas.max_executions_per_hour"), 0),
+		MaxTimeout:           max(viper.GetInt("quotas.max_timeout"), 0),
+	}
+}
+
+// apply returns q with the overrides set in o
+func (o Overrides) apply(q Quotas) Quotas {
+	for _, f := range []struct {
+		override *int
+		quota    *int
+	}{
+		{o.MaxTasks, &q.MaxTasks},
+		{o.MinInterval, &q.MinInterval},
+		{o.MaxExecutionsPerHour, &q.MaxExecutionsPerHour},
+		{o.MaxTimeout, &q.MaxTimeout},
+	} {
+		if f.override != nil {
+			*f.quota = *f.override
+		}
+	}
+
+	return q
+}
+
+// ExceededError is returned when a tenant goes over one of its quotas
+type ExceededError struct {
+	Quota string // json name of the quota
+	Limit int
+	Value int64
+}
+
+func (e *ExceededError) Error() string {
+	if e.Quota == "min_interval" {
+		return fmt.Sprintf("quota min_interval exceeded: runs must be at least %ds apart, got %ds",
+			e.Limit, e.Value)
+	}
+
+	return fmt.Sprintf("quota %s exceeded: limit is %d, got %d", e.Quota, e.Limit, e.Value)
+}
+
+// IsExceeded reports whether err is caused by a quota
+func IsExceeded(err error) bool {
+	var exceeded *ExceededError
+	return errors.As(err, &exceeded)
+}
+
+// CheckTasks checks the number of tasks of the tenant
+func (q Quotas) CheckTasks(count int64) error {
+	return checkMax("max_tasks", q.MaxTasks, count)
+}
+
+// CheckTimeout checks the timeout of a task in seconds
+func (q Quotas) CheckTimeout(timeout int) error {
+	return checkMax("max_timeout", q.MaxTimeout, int64(timeout))
+}
+
+// CheckExecutions checks the number of executions fired within an hour
+func (q Quotas) CheckExecutions(count int64) error {
+	return checkMax("max_executions_per_hour", q.MaxExecutionsPerHour, count)
+}
+
+// CheckInterval checks the shortest interval between two runs of a task
+func (q Quotas) CheckInterval(interval int64) error {
+	if q.MinInterval > 0 && interval < int64(q.MinInterval) {
+		return &ExceededError{Quota: "min_interval", Limit: q.MinInterval, Value: interval}
+	}
+
+	return nil
+}
+
+func checkMax(quota string, limit int, value int64) error {
+	if limit > 0 && value > int64(limit) {
+		return &ExceededError{Quota: quota, Limit: limit, Value: value}
+	}
+
+	return nil
+}
+
+// GetQuotas returns the quotas of the tenant, the configured
+// quotas with the overrides of the tenant applied
+func (dao *QuotaDAO) GetQuotas(ctx context.Context, tenantID string) (Quotas, error) {
+	o, err := dao.GetOverrides(ctx, tenantID)
+	if err != nil {
+		return Quotas{}, err
+	}
+
+	return o.apply(Defaults()), nil
+}
+
+// GetOverrides returns the quotas set for the tenant, an unknown
+// tenant has no overrides
+func (dao *QuotaDAO) GetOverrides(ctx context.Context, tenantID string) (Overrides, error) {
+	db := dao.RO()
+	query := `
+		SELECT max_tasks, min_interval, max_executions_per_hour, max_timeout
+		FROM tenants
+		WHERE id = $1
+	`
+	o := Overrides{}
+	if err := db.QueryRowContext(ctx, query, tenantID).Scan(
+		&o.MaxTasks, &o.MinInterval, &o.MaxExecutionsPerHour, &o.MaxTimeout,
+	); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return o, fmt.Errorf("get tenant quotas: %w", err)
+	}
+
+	return o, nil
+}
+
+// SetOverrides replaces the quotas set for the tenant and reports
+// whether the tenant exists
+func (dao *QuotaDAO) SetOverrides(ctx context.Context, tenantID string, o Overrides) (bool, error) {
+	db := dao.RW()
+	query := `
+		UPDATE tenants
+		SET
+			max_tasks = $1,
+			min_interval = $2,
+			max_executions_per_hour = $3,
+			max_timeout = $4,
+			updated_at = $5
+		WHERE id = $6
+	`
+	res, err := db.ExecContext(ctx, query,
+		o.MaxTasks, o.MinInterval, o.MaxExecutionsPerHour, o.MaxTimeout,
+		dao.TimeNow(), tenantID)
+	if err != nil {
+		return false, fmt.Errorf("set tenant quotas: %w", err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("set tenant quotas: %w", err)
+	}
+
+	return updated > 0, nil
+}
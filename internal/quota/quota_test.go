@@ -0,0 +1,47 @@
+package quota
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotas(t *testing.T) {
+	t.Run("zero-is-unlimited", func(t *testing.T) {
+		q := Quotas{}
+		assert.NoError(t, q.CheckTasks(1_000_000))
+		assert.NoError(t, q.CheckTimeout(86400))
+		assert.NoError(t, q.CheckExecutions(1_000_000))
+		assert.NoError(t, q.CheckInterval(1))
+	})
+
+	t.Run("limits-are-inclusive", func(t *testing.T) {
+		q := Quotas{MaxTasks: 10, MinInterval: 60, MaxExecutionsPerHour: 100, MaxTimeout: 300}
+		assert.NoError(t, q.CheckTasks(10))
+		assert.NoError(t, q.CheckTimeout(300))
+		assert.NoError(t, q.CheckExecutions(100))
+		assert.NoError(t, q.CheckInterval(60))
+	})
+
+	t.Run("exceeded", func(t *testing.T) {
+		q := Quotas{MaxTasks: 10, MinInterval: 60}
+
+		err := q.CheckTasks(11)
+		assert.True(t, IsExceeded(err))
+		assert.EqualError(t, err, "quota max_tasks exceeded: limit is 10, got 11")
+
+		err = q.CheckInterval(30)
+		assert.True(t, IsExceeded(err))
+		assert.EqualError(t, err, "quota min_interval exceeded: runs must be at least 60s apart, got 30s")
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		q := Overrides{MaxTasks: typePtr(5), MaxTimeout: typePtr(0)}.apply(
+			Quotas{MaxTasks: 10, MinInterval: 60, MaxTimeout: 300})
+		assert.Equal(t, Quotas{MaxTasks: 5, MinInterval: 60}, q)
+	})
+}
+
+func typePtr[T any](t T) *T {
+	return &t
+}
@@ -0,0 +1,315 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/stuckinforloop/ticker/internal/jsonpath"
+)
+
+// SuccessCriteria decide whether the response of an execution is a
+// success, every rule that is set must hold. Criteria without status
+// codes only accept 2xx responses. Tasks without criteria succeed
+// whatever the status code, as they did before criteria existed.
+type SuccessCriteria struct {
+	// StatusCodes accepts a code ("204"), a class ("2xx")
+	// or an inclusive range ("200-299")
+	StatusCodes []string `json:"status_codes,omitempty"`
+
+	// MaxLatency is the time in milliseconds the whole request may take
+	MaxLatency *int `json:"max_latency_ms,omitempty"`
+
+	// Headers must be present in the response, with the given
+	// value unless it is empty
+	Headers map[string]string `json:"headers,omitempty"`
+
+	Body []Assertion `json:"body,omitempty"`
+}
+
+// Assertion checks the body of a response as text, or the value at
+// JSONPath if the body is JSON. Equals compares the value as JSON and
+// requires a JSONPath, Contains and Matches (a regular expression) check
+// its text. A JSONPath alone checks that the value exists.
+type Assertion struct {
+	JSONPath string          `json:"json_path,omitempty"`
+	Equals   json.RawMessage `json:"equals,omitempty"`
+	Contains string          `json:"contains,omitempty"`
+	Matches  string          `json:"matches,omitempty"`
+}
+
+// AssertionFailure records the first rule of the success criteria
+// not met by a response
+type AssertionFailure struct {
+	Assertion string `json:"assertion"` // e.g. "status_codes" or "body[0].contains"
+	Expected  string `json:"expected"`
+	Actual    string `json:"actual"`
+}
+
+func (f *AssertionFailure) Error() string {
+	return fmt.Sprintf("assertion %s failed: expected %s, got %s", f.Assertion, f.Expected, f.Actual)
+}
+
+// maxActualLength bounds the length of the actual value recorded
+// on a failure, bodies can be large
+const maxActualLength = 256
+
+var defaultStatusCodes = []string{"2xx"}
+
+// parseStatusCodes returns the inclusive bounds of a status code rule
+func parseStatusCodes(rule string) (int, int, error) {
+	if class, ok := strings.CutSuffix(strings.ToLower(rule), "xx"); ok && len(class) == 1 {
+		c, err := strconv.Atoi(class)
+		if err == nil && c >= 1 && c <= 5 {
+			return c * 100, c*100 + 99, nil
+		}
+	}
+
+	from, to, isRange := strings.Cut(rule, "-")
+	if !isRange {
+		to = from
+	}
+
+	lo, err := strconv.Atoi(from)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid status code: %s", rule)
+	}
+
+	hi, err := strconv.Atoi(to)
+	if err != nil || lo < 100 || hi > 599 || lo > hi {
+		return 0, 0, fmt.Errorf("invalid status code: %s", rule)
+	}
+
+	return lo, hi, nil
+}
+
+// Validate checks that every rule of the criteria can be evaluated
+func (c *SuccessCriteria) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	for _, rule := range c.StatusCodes {
+		if _, _, err := parseStatusCodes(rule); err != nil {
+			return err
+		}
+	}
+
+	if c.MaxLatency != nil && *c.MaxLatency < 1 {
+		return errors.New("max_latency_ms must be at least 1")
+	}
+
+	for name := range c.Headers {
+		if name == "" {
+			return errors.New("header name cannot be empty")
+		}
+	}
+
+	for i, a := range c.Body {
+		if a.JSONPath == "" && a.Equals == nil && a.Contains == "" && a.Matches == "" {
+			return fmt.Errorf("body[%d]: assertion is empty", i)
+		}
+
+		if a.JSONPath != "" {
+			if _, err := jsonpath.Parse(a.JSONPath); err != nil {
+				return fmt.Errorf("body[%d]: %w", i, err)
+			}
+		}
+
+		if a.Equals != nil {
+			if a.JSONPath == "" {
+				return fmt.Errorf("body[%d]: equals requires json_path", i)
+			}
+
+			if !json.Valid(a.Equals) {
+				return fmt.Errorf("body[%d]: equals is not valid json", i)
+			}
+		}
+
+		if a.Matches != "" {
+			if _, err := regexp.Compile(a.Matches); err != nil {
+				return fmt.Errorf("body[%d]: invalid matches: %w", i, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Check returns the first rule not met by a response, nil if the
+// response is a success or there are no criteria. latency is in
+// milliseconds.
+func (c *SuccessCriteria) Check(statusCode int, header http.Header, body string, latency float64) *AssertionFailure {
+	if c == nil {
+		return nil
+	}
+
+	if f := c.checkStatusCode(statusCode); f != nil {
+		return f
+	}
+
+	if c.MaxLatency != nil && latency > float64(*c.MaxLatency) {
+		return &AssertionFailure{
+			Assertion: "max_latency_ms",
+			Expected:  fmt.Sprintf("at most %dms", *c.MaxLatency),
+			Actual:    fmt.Sprintf("%.0fms", latency),
+		}
+	}
+
+	names := make([]string, 0, len(c.Headers))
+	for name := range c.Headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if f := checkHeader(name, c.Headers[name], header); f != nil {
+			return f
+		}
+	}
+
+	var doc any
+	var docErr error
+	parsed := false
+	for i, a := range c.Body {
+		// the body is only parsed if an assertion needs it
+		if a.JSONPath != "" && !parsed {
+			docErr = json.Unmarshal([]byte(body), &doc)
+			parsed = true
+		}
+
+		if f := a.check(body, doc, docErr); f != nil {
+			f.Assertion = fmt.Sprintf("body[%d].%s", i, f.Assertion)
+			return f
+		}
+	}
+
+	return nil
+}
+
+func (c *SuccessCriteria) checkStatusCode(statusCode int) *AssertionFailure {
+	rules := c.StatusCodes
+	if len(rules) == 0 {
+		rules = defaultStatusCodes
+	}
+
+	for _, rule := range rules {
+		lo, hi, err := parseStatusCodes(rule)
+		if err == nil && statusCode >= lo && statusCode <= hi {
+			return nil
+		}
+	}
+
+	return &AssertionFailure{
+		Assertion: "status_codes",
+		Expected:  strings.Join(rules, ", "),
+		Actual:    strconv.Itoa(statusCode),
+	}
+}
+
+func checkHeader(name, value string, header http.Header) *AssertionFailure {
+	values := header.Values(name)
+	if len(values) == 0 {
+		expected := "present"
+		if value != "" {
+			expected = strconv.Quote(value)
+		}
+
+		return &AssertionFailure{
+			Assertion: "headers." + name,
+			Expected:  expected,
+			Actual:    "missing",
+		}
+	}
+
+	if value != "" && !slices.Contains(values, value) {
+		return &AssertionFailure{
+			Assertion: "headers." + name,
+			Expected:  strconv.Quote(value),
+			Actual:    truncate(strconv.Quote(strings.Join(values, ", "))),
+		}
+	}
+
+	return nil
+}
+
+// check evaluates the assertion against the body, doc is the body
+// decoded as JSON unless docErr is set
+func (a *Assertion) check(body string, doc any, docErr error) *AssertionFailure {
+	text := body
+	if a.JSONPath != "" {
+		if docErr != nil {
+			return &AssertionFailure{
+				Assertion: "json_path",
+				Expected:  "a json body",
+				Actual:    truncate(strconv.Quote(body)),
+			}
+		}
+
+		// validated with the task
+		path, _ := jsonpath.Parse(a.JSONPath)
+		value, ok := path.Lookup(doc)
+		if !ok {
+			return &AssertionFailure{
+				Assertion: "json_path",
+				Expected:  "a value at " + a.JSONPath,
+				Actual:    "missing",
+			}
+		}
+
+		if a.Equals != nil {
+			var expected any
+			if err := json.Unmarshal(a.Equals, &expected); err != nil || !reflect.DeepEqual(expected, value) {
+				actual, _ := json.Marshal(value)
+				return &AssertionFailure{
+					Assertion: "equals",
+					Expected:  string(a.Equals),
+					Actual:    truncate(string(actual)),
+				}
+			}
+		}
+
+		// strings are checked without their quotes
+		if s, ok := value.(string); ok {
+			text = s
+		} else {
+			b, _ := json.Marshal(value)
+			text = string(b)
+		}
+	}
+
+	if a.Contains != "" && !strings.Contains(text, a.Contains) {
+		return &AssertionFailure{
+			Assertion: "contains",
+			Expected:  strconv.Quote(a.Contains),
+			Actual:    truncate(strconv.Quote(text)),
+		}
+	}
+
+	if a.Matches != "" {
+		re, err := regexp.Compile(a.Matches)
+		if err != nil || !re.MatchString(text) {
+			return &AssertionFailure{
+				Assertion: "matches",
+				Expected:  "/" + a.Matches + "/",
+				Actual:    truncate(strconv.Quote(text)),
+			}
+		}
+	}
+
+	return nil
+}
+
+func truncate(s string) string {
+	if len(s) <= maxActualLength {
+		return s
+	}
+
+	return strings.ToValidUTF8(s[:maxActualLength], "") + "..."
+}
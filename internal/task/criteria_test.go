@@ -0,0 +1,120 @@
+package task
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuccessCriteria(t *testing.T) {
+	t.Run("no-criteria", func(t *testing.T) {
+		var c *SuccessCriteria
+		assert.Nil(t, c.Check(204, http.Header{}, "", 10))
+		assert.Nil(t, c.Check(500, http.Header{}, "oops", 10))
+	})
+
+	t.Run("default", func(t *testing.T) {
+		c := &SuccessCriteria{}
+		assert.Nil(t, c.Check(204, http.Header{}, "", 10))
+
+		f := c.Check(500, http.Header{}, "oops", 10)
+		assert.Equal(t, &AssertionFailure{Assertion: "status_codes", Expected: "2xx", Actual: "500"}, f)
+		assert.EqualError(t, f, "assertion status_codes failed: expected 2xx, got 500")
+	})
+
+	t.Run("status-codes", func(t *testing.T) {
+		c := &SuccessCriteria{StatusCodes: []string{"2xx", "404", "500-502"}}
+		for _, code := range []int{200, 299, 404, 500, 502} {
+			assert.Nil(t, c.Check(code, http.Header{}, "", 10), code)
+		}
+
+		for _, code := range []int{301, 403, 503} {
+			assert.NotNil(t, c.Check(code, http.Header{}, "", 10), code)
+		}
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		c := &SuccessCriteria{MaxLatency: typePtr(100)}
+		assert.Nil(t, c.Check(200, http.Header{}, "", 100))
+		assert.Equal(t, "max_latency_ms", c.Check(200, http.Header{}, "", 100.5).Assertion)
+	})
+
+	t.Run("headers", func(t *testing.T) {
+		c := &SuccessCriteria{Headers: map[string]string{
+			"x-request-id": "",
+			"Content-Type": "application/json",
+		}}
+		header := http.Header{}
+		header.Set("Content-Type", "text/html")
+
+		f := c.Check(200, header, "", 10)
+		assert.Equal(t, &AssertionFailure{
+			Assertion: "headers.Content-Type",
+			Expected:  `"application/json"`,
+			Actual:    `"text/html"`,
+		}, f)
+
+		header.Set("Content-Type", "application/json")
+		assert.Equal(t, "headers.x-request-id", c.Check(200, header, "", 10).Assertion)
+
+		header.Set("X-Request-Id", "abc")
+		assert.Nil(t, c.Check(200, header, "", 10))
+	})
+
+	t.Run("body", func(t *testing.T) {
+		body := `{"status": "ok", "data": {"count": 3, "items": ["a", "b"]}}`
+		for _, tc := range []struct {
+			name      string
+			assertion Assertion
+			failure   string
+		}{
+			{"exists", Assertion{JSONPath: "$.data.items[1]"}, ""},
+			{"missing", Assertion{JSONPath: "$.data.total"}, "body[0].json_path"},
+			{"equals", Assertion{JSONPath: "$.data.count", Equals: json.RawMessage(`3`)}, ""},
+			{"equals-object", Assertion{JSONPath: "$.data.items", Equals: json.RawMessage(`["a","b"]`)}, ""},
+			{"not-equals", Assertion{JSONPath: "$.status", Equals: json.RawMessage(`"down"`)}, "body[0].equals"},
+			{"contains", Assertion{Contains: `"ok"`}, ""},
+			{"contains-value", Assertion{JSONPath: "$.status", Contains: "o"}, ""},
+			{"not-contains", Assertion{Contains: "error"}, "body[0].contains"},
+			{"matches", Assertion{JSONPath: "$.status", Matches: "^ok$"}, ""},
+			{"not-matches", Assertion{Matches: `"count":\s*0`}, "body[0].matches"},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				c := &SuccessCriteria{Body: []Assertion{tc.assertion}}
+				assert.NoError(t, c.Validate())
+
+				f := c.Check(200, http.Header{}, body, 10)
+				if tc.failure == "" {
+					assert.Nil(t, f)
+				} else if assert.NotNil(t, f) {
+					assert.Equal(t, tc.failure, f.Assertion)
+				}
+			})
+		}
+
+		c := &SuccessCriteria{Body: []Assertion{{JSONPath: "$.status"}}}
+		f := c.Check(200, http.Header{}, "<h1>ok</h1>", 10)
+		assert.Equal(t, &AssertionFailure{
+			Assertion: "body[0].json_path",
+			Expected:  "a json body",
+			Actual:    `"<h1>ok</h1>"`,
+		}, f)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		for _, c := range []SuccessCriteria{
+			{StatusCodes: []string{"6xx"}},
+			{StatusCodes: []string{"299-200"}},
+			{StatusCodes: []string{"ok"}},
+			{MaxLatency: typePtr(0)},
+			{Body: []Assertion{{}}},
+			{Body: []Assertion{{JSONPath: "status"}}},
+			{Body: []Assertion{{Equals: json.RawMessage(`1`)}}},
+			{Body: []Assertion{{Matches: "("}}},
+		} {
+			assert.Error(t, c.Validate(), c)
+		}
+	})
+}
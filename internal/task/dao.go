@@ -2,12 +2,15 @@ package task
 
 import "github.com/stuckinforloop/ticker/internal/dao"
 
+// TaskDAO reads and writes the groups and tasks of a single tenant
 type TaskDAO struct {
 	*dao.DAO
+	TenantID string
 }
 
-func NewTaskDAO(dao *dao.DAO) *TaskDAO {
+func NewTaskDAO(dao *dao.DAO, tenantID string) *TaskDAO {
 	return &TaskDAO{
 		dao,
+		tenantID,
 	}
 }
@@ -0,0 +1,187 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+func (dao *TaskDAO) CreateGroup(ctx context.Context, g *Group) (*Group, error) {
+	g.ID = dao.ULIDSource.New(uint64(dao.TimeNow()))
+	g.TenantID = dao.TenantID
+	g.CreatedAt = dao.TimeNow()
+	g.UpdatedAt = dao.TimeNow()
+
+	db := dao.RW()
+	query := `
+		INSERT INTO groups (
+			id, tenant_id, name, created_at, updated_at
+		) Values (
+			$1, $2, $3, $4, $5
+		)
+	`
+	if _, err := db.ExecContext(ctx, query,
+		g.ID, g.TenantID, g.Name, g.CreatedAt, g.UpdatedAt,
+	); err != nil {
+		return nil, fmt.Errorf("create group: %w", err)
+	}
+
+	return g, nil
+}
+
+func (dao *TaskDAO) GetGroups(ctx context.Context) ([]Group, error) {
+	db := dao.RO()
+	query := `
+		SELECT id, tenant_id, name, created_at, updated_at
+		FROM groups
+		WHERE tenant_id = $1
+		ORDER BY name, id
+	`
+	rows, err := db.QueryContext(ctx, query, dao.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("get groups: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []Group{}
+	for rows.Next() {
+		g := Group{}
+		if err := rows.Scan(&g.ID, &g.TenantID, &g.Name, &g.CreatedAt, &g.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		groups = append(groups, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration: %w", err)
+	}
+
+	return groups, nil
+}
+
+// GetGroup returns the group with the given id or nil if it does not exist
+func (dao *TaskDAO) GetGroup(ctx context.Context, id string) (*Group, error) {
+	db := dao.RO()
+	query := `
+		SELECT id, tenant_id, name, created_at, updated_at
+		FROM groups
+		WHERE id = $1 AND tenant_id = $2
+	`
+	g := Group{}
+	if err := db.QueryRowContext(ctx, query, id, dao.TenantID).Scan(
+		&g.ID, &g.TenantID, &g.Name, &g.CreatedAt, &g.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("get group: %w", err)
+	}
+
+	return &g, nil
+}
+
+// RenameGroup returns the renamed group or nil if it does not exist
+func (dao *TaskDAO) RenameGroup(ctx context.Context, id string, name string) (*Group, error) {
+	db := dao.RW()
+	query := `
+		UPDATE groups
+		SET
+			name = $1,
+			updated_at = $2
+		WHERE id = $3 AND tenant_id = $4
+	`
+	if _, err := db.ExecContext(ctx, query, name, dao.TimeNow(), id, dao.TenantID); err != nil {
+		return nil, fmt.Errorf("rename group: %w", err)
+	}
+
+	return dao.GetGroup(ctx, id)
+}
+
+// DeleteGroup deletes the group, its tasks are kept without a group
+func (dao *TaskDAO) DeleteGroup(ctx context.Context, id string) error {
+	return dao.WithTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			UPDATE tasks
+			SET
+				group_id = NULL,
+				updated_at = $1
+			WHERE group_id = $2 AND tenant_id = $3
+		`
+		if _, err := tx.ExecContext(ctx, query, dao.TimeNow(), id, dao.TenantID); err != nil {
+			return fmt.Errorf("ungroup tasks: %w", err)
+		}
+
+		query = `
+			DELETE FROM groups
+			WHERE id = $1 AND tenant_id = $2
+		`
+		if _, err := tx.ExecContext(ctx, query, id, dao.TenantID); err != nil {
+			return fmt.Errorf("delete group: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// PauseGroup pauses the active tasks of the group and returns
+// how many tasks were paused
+func (dao *TaskDAO) PauseGroup(ctx context.Context, id string, p Pause) (int64, error) {
+	db := dao.RW()
+	query := pauseQuery + `
+		WHERE group_id = $7 AND status = $8 AND tenant_id = $9
+	`
+	res, err := db.ExecContext(ctx, query, append(dao.pauseArgs(GroupPausedReason, p), id, StatusActive, dao.TenantID)...)
+	if err != nil {
+		return 0, fmt.Errorf("pause group: %w", err)
+	}
+
+	paused, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("pause group: %w", err)
+	}
+
+	return paused, nil
+}
+
+// ResumeGroup enables the tasks paused by PauseGroup and returns how
+// many tasks were resumed. Tasks paused on their own or disabled after
+// failing are left as they are.
+func (dao *TaskDAO) ResumeGroup(ctx context.Context, id string) (int64, error) {
+	db := dao.RO()
+	query := `
+		SELECT ` + Columns + `
+		FROM tasks
+		WHERE group_id = $1 AND status = $2 AND disabled_reason = $3
+			AND tenant_id = $4
+	`
+	rows, err := db.QueryContext(ctx, query, id, StatusDisabled, GroupPausedReason, dao.TenantID)
+	if err != nil {
+		return 0, fmt.Errorf("get group tasks: %w", err)
+	}
+
+	return dao.enableTasks(ctx, rows)
+}
+
+// DeleteGroupTasks deletes the tasks of the group and returns
+// how many tasks were deleted
+func (dao *TaskDAO) DeleteGroupTasks(ctx context.Context, id string) (int64, error) {
+	db := dao.RW()
+	query := `
+		DELETE FROM tasks
+		WHERE group_id = $1 AND tenant_id = $2
+	`
+	res, err := db.ExecContext(ctx, query, id, dao.TenantID)
+	if err != nil {
+		return 0, fmt.Errorf("delete group tasks: %w", err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("delete group tasks: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,108 @@
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stuckinforloop/ticker/database"
+)
+
+func TestGroup(t *testing.T) {
+	db := database.NewTestDB(t)
+	now := int64(1700000000)
+	taskDAO := newTestTaskDAO(t, db, &now)
+
+	status := func(t *testing.T, id string) Status {
+		tk, err := taskDAO.GetTask(context.Background(), id)
+		assert.NoError(t, err)
+
+		return tk.Status
+	}
+
+	t.Run("crud", func(t *testing.T) {
+		ctx := context.Background()
+
+		g, err := taskDAO.CreateGroup(ctx, &Group{Name: "crud"})
+		assert.NoError(t, err)
+
+		got, err := taskDAO.GetGroup(ctx, g.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "crud", got.Name)
+
+		got, err = taskDAO.RenameGroup(ctx, g.ID, "renamed")
+		assert.NoError(t, err)
+		assert.Equal(t, "renamed", got.Name)
+
+		groups, err := taskDAO.GetGroups(ctx)
+		assert.NoError(t, err)
+		assert.Contains(t, groups, *got)
+
+		// the tasks of a deleted group are kept without a group
+		tk := createTestTask(t, taskDAO, &g.ID)
+		assert.NoError(t, taskDAO.DeleteGroup(ctx, g.ID))
+
+		got, err = taskDAO.GetGroup(ctx, g.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+
+		tk, err = taskDAO.GetTask(ctx, tk.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, tk.GroupID)
+	})
+
+	t.Run("pause-resume", func(t *testing.T) {
+		ctx := context.Background()
+
+		g, err := taskDAO.CreateGroup(ctx, &Group{Name: "pause-resume"})
+		assert.NoError(t, err)
+
+		active := createTestTask(t, taskDAO, &g.ID)
+		paused := createTestTask(t, taskDAO, &g.ID)
+		failed := createTestTask(t, taskDAO, &g.ID)
+		other := createTestTask(t, taskDAO, nil)
+
+		_, err = taskDAO.PauseTask(ctx, paused.ID, Pause{})
+		assert.NoError(t, err)
+
+		_, err = db.ExecContext(ctx, `
+			UPDATE tasks
+			SET status = $1, disabled_reason = 'failed 20 times in a row'
+			WHERE id = $2
+		`, StatusDisabled, failed.ID)
+		assert.NoError(t, err)
+
+		n, err := taskDAO.PauseGroup(ctx, g.ID, Pause{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		assert.Equal(t, StatusDisabled, status(t, active.ID))
+		assert.Equal(t, StatusActive, status(t, other.ID))
+
+		// only the task paused along with the group is resumed
+		n, err = taskDAO.ResumeGroup(ctx, g.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		assert.Equal(t, StatusActive, status(t, active.ID))
+		assert.Equal(t, StatusDisabled, status(t, paused.ID))
+		assert.Equal(t, StatusDisabled, status(t, failed.ID))
+	})
+
+	t.Run("delete-tasks", func(t *testing.T) {
+		ctx := context.Background()
+
+		g, err := taskDAO.CreateGroup(ctx, &Group{Name: "delete-tasks"})
+		assert.NoError(t, err)
+
+		createTestTask(t, taskDAO, &g.ID)
+		createTestTask(t, taskDAO, &g.ID)
+		other := createTestTask(t, taskDAO, nil)
+
+		n, err := taskDAO.DeleteGroupTasks(ctx, g.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+
+		tk, err := taskDAO.GetTask(ctx, other.ID)
+		assert.NoError(t, err)
+		assert.NotNil(t, tk)
+	})
+}
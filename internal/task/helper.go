@@ -1,5 +1,9 @@
 package task
 
+// defaultTimeout is the timeout in seconds of tasks that do not set one,
+// lowered to the max_timeout quota of their tenant
+const defaultTimeout = 3600
+
 func (t *TaskDAO) fillDefaults(task *Task) {
 	if task.ID == "" {
 		id := t.ULIDSource.New(uint64(t.TimeNow()))
@@ -14,8 +18,12 @@ func (t *TaskDAO) fillDefaults(task *Task) {
 		task.Timezone = UTC
 	}
 
+	if task.Type == "" {
+		task.Type = TypeHTTP
+	}
+
 	if task.Timeout == nil {
-		task.Timeout = typePtr(3600)
+		task.Timeout = typePtr(defaultTimeout)
 	}
 
 	if task.Instances == nil {
@@ -26,6 +34,14 @@ func (t *TaskDAO) fillDefaults(task *Task) {
 		task.RetryAfter = typePtr(0)
 	}
 
+	if task.RetryBackoff == "" {
+		task.RetryBackoff = BackoffFixed
+	}
+
+	if task.MaxAttempts == nil {
+		task.MaxAttempts = typePtr(3)
+	}
+
 	if task.FailureThreshold == nil {
 		task.FailureThreshold = typePtr(20)
 	}
@@ -33,6 +49,18 @@ func (t *TaskDAO) fillDefaults(task *Task) {
 	if task.NotifyEvery == nil {
 		task.NotifyEvery = typePtr(0)
 	}
+
+	if task.MisfirePolicy == "" {
+		task.MisfirePolicy = MisfireSkip
+	}
+
+	if task.MisfireLimit == nil {
+		task.MisfireLimit = typePtr(10)
+	}
+
+	if task.ConcurrencyPolicy == "" {
+		task.ConcurrencyPolicy = ConcurrencyAllow
+	}
 }
 
 func typePtr[T any](t T) *T {
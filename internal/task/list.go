@@ -0,0 +1,222 @@
+package task
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type SortField string
+
+const (
+	SortCreatedAt SortField = "created_at"
+	SortUpdatedAt SortField = "updated_at"
+	SortName      SortField = "name"
+)
+
+// ListFilter selects the tasks returned by ListTasks. Zero values
+// do not filter.
+type ListFilter struct {
+	Statuses    []Status
+	GroupID     string
+	Name        string // case insensitive substring of the name
+	URLHost     string
+	CreatedFrom *int64 // inclusive
+	CreatedTo   *int64 // exclusive
+	UpdatedFrom *int64 // inclusive
+	UpdatedTo   *int64 // exclusive
+
+	Sort SortField
+	Desc bool
+
+	// Cursor is returned by ListTasks to fetch the next page, it is
+	// only valid with the same sort order
+	Cursor string
+	Limit  int
+}
+
+// cursor is the sort key of the last task of a page
+type cursor struct {
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+func (f *ListFilter) sortColumn() string {
+	switch f.Sort {
+	case SortUpdatedAt, SortName:
+		return string(f.Sort)
+	}
+
+	return string(SortCreatedAt)
+}
+
+// sortValue returns the value of the sort column of t
+func (f *ListFilter) sortValue(t *Task) string {
+	switch f.sortColumn() {
+	case string(SortUpdatedAt):
+		return strconv.FormatInt(t.UpdatedAt, 10)
+	case string(SortName):
+		return *t.Name
+	}
+
+	return strconv.FormatInt(t.CreatedAt, 10)
+}
+
+func (f *ListFilter) encodeCursor(t *Task) string {
+	b, _ := json.Marshal(cursor{Value: f.sortValue(t), ID: t.ID})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor returns the sort value and id stored in the cursor
+func (f *ListFilter) decodeCursor() (any, string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(f.Cursor)
+	if err != nil {
+		return nil, "", ErrInvalidCursor
+	}
+
+	c := cursor{}
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == "" {
+		return nil, "", ErrInvalidCursor
+	}
+
+	if f.sortColumn() == string(SortName) {
+		return c.Value, c.ID, nil
+	}
+
+	v, err := strconv.ParseInt(c.Value, 10, 64)
+	if err != nil {
+		return nil, "", ErrInvalidCursor
+	}
+
+	return v, c.ID, nil
+}
+
+// where returns the conditions of the filter, excluding the cursor
+func (f *ListFilter) where(arg func(v any) string) []string {
+	conditions := []string{"TRUE"}
+
+	if len(f.Statuses) > 0 {
+		statuses := make([]string, len(f.Statuses))
+		for i, s := range f.Statuses {
+			statuses[i] = arg(s)
+		}
+		conditions = append(conditions, "status IN ("+strings.Join(statuses, ", ")+")")
+	}
+
+	if f.GroupID != "" {
+		conditions = append(conditions, "group_id = "+arg(f.GroupID))
+	}
+
+	if f.Name != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(f.Name)
+		conditions = append(conditions, "name ILIKE "+arg("%"+escaped+"%"))
+	}
+
+	if f.URLHost != "" {
+		conditions = append(conditions,
+			`lower(substring(url from '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^@/]*@)?([^/:?#]+)')) = `+
+				arg(strings.ToLower(f.URLHost)))
+	}
+
+	ranges := []struct {
+		column string
+		from   *int64
+		to     *int64
+	}{
+		{"created_at", f.CreatedFrom, f.CreatedTo},
+		{"updated_at", f.UpdatedFrom, f.UpdatedTo},
+	}
+	for _, r := range ranges {
+		if r.from != nil {
+			conditions = append(conditions, r.column+" >= "+arg(*r.from))
+		}
+
+		if r.to != nil {
+			conditions = append(conditions, r.column+" < "+arg(*r.to))
+		}
+	}
+
+	return conditions
+}
+
+// ListTasks returns a page of the tasks matching f along with the cursor
+// of the next page, empty on the last page, and the number of matching
+// tasks across all pages.
+func (dao *TaskDAO) ListTasks(ctx context.Context, f ListFilter) ([]Task, string, int64, error) {
+	args := []any{}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{"tenant_id = " + arg(dao.TenantID)}
+	conditions = append(conditions, f.where(arg)...)
+
+	db := dao.RO()
+	var total int64
+	query := `
+		SELECT COUNT(*)
+		FROM tasks
+		WHERE ` + strings.Join(conditions, " AND ")
+	if err := db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		return nil, "", 0, fmt.Errorf("count tasks: %w", err)
+	}
+
+	column := f.sortColumn()
+	direction, comparison := "ASC", ">"
+	if f.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if f.Cursor != "" {
+		value, id, err := f.decodeCursor()
+		if err != nil {
+			return nil, "", 0, err
+		}
+
+		conditions = append(conditions,
+			fmt.Sprintf("(%s, id) %s (%s, %s)", column, comparison, arg(value), arg(id)))
+	}
+
+	// one more row tells whether there is a next page
+	query = `
+		SELECT ` + Columns + `
+		FROM tasks
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY ` + column + ` ` + direction + `, id ` + direction + `
+		LIMIT ` + arg(f.Limit+1)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("list tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []Task{}
+	for rows.Next() {
+		t, err := Scan(rows)
+		if err != nil {
+			return nil, "", 0, fmt.Errorf("scan row: %w", err)
+		}
+
+		tasks = append(tasks, *t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, "", 0, fmt.Errorf("rows iteration: %w", err)
+	}
+
+	next := ""
+	if len(tasks) > f.Limit {
+		tasks = tasks[:f.Limit]
+		next = f.encodeCursor(&tasks[len(tasks)-1])
+	}
+
+	return tasks, next, total, nil
+}
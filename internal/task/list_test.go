@@ -0,0 +1,61 @@
+package task
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListCursor(t *testing.T) {
+	name := "backup"
+	tsk := &Task{ID: "01HF7YAT00000000000000000A", Name: &name, CreatedAt: 1700000000}
+
+	t.Run("created-at", func(t *testing.T) {
+		f := ListFilter{}
+		f.Cursor = f.encodeCursor(tsk)
+
+		value, id, err := f.decodeCursor()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1700000000), value)
+		assert.Equal(t, tsk.ID, id)
+	})
+
+	t.Run("name", func(t *testing.T) {
+		f := ListFilter{Sort: SortName}
+		f.Cursor = f.encodeCursor(tsk)
+
+		value, _, err := f.decodeCursor()
+		assert.NoError(t, err)
+		assert.Equal(t, "backup", value)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		f := ListFilter{Cursor: "not a cursor"}
+		_, _, err := f.decodeCursor()
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
+
+func TestListWhere(t *testing.T) {
+	args := []any{}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	from := int64(10)
+	f := ListFilter{
+		Statuses:    []Status{StatusActive, StatusDisabled},
+		Name:        "50%_off",
+		CreatedFrom: &from,
+	}
+
+	assert.Equal(t, []string{
+		"TRUE",
+		"status IN ($1, $2)",
+		"name ILIKE $3",
+		"created_at >= $4",
+	}, f.where(arg))
+	assert.Equal(t, []any{StatusActive, StatusDisabled, `%50\%\_off%`, int64(10)}, args)
+}
@@ -0,0 +1,95 @@
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidPatch = errors.New("patch must be a JSON object")
+
+// MergePatch applies a JSON merge patch (RFC 7396) to a copy of t. Fields
+// set to null are reset to their default, fields maintained by ticker
+// cannot be patched and keep their current value.
+func MergePatch(t *Task, patch []byte) (*Task, error) {
+	var p any
+	if err := decodeJSON(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+
+	if _, ok := p.(map[string]any); !ok {
+		return nil, ErrInvalidPatch
+	}
+
+	current, err := json.Marshal(t)
+	if err != nil {
+		return nil, fmt.Errorf("marshal task: %w", err)
+	}
+
+	var target any
+	if err := decodeJSON(current, &target); err != nil {
+		return nil, fmt.Errorf("unmarshal task: %w", err)
+	}
+
+	merged, err := json.Marshal(mergePatch(target, p))
+	if err != nil {
+		return nil, fmt.Errorf("marshal patched task: %w", err)
+	}
+
+	patched := &Task{}
+	if err := json.Unmarshal(merged, patched); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+	patched.CopyReadOnly(t)
+
+	return patched, nil
+}
+
+// CopyReadOnly sets the fields maintained by ticker to the ones of from
+func (t *Task) CopyReadOnly(from *Task) {
+	t.ID = from.ID
+	t.TenantID = from.TenantID
+	t.Status = from.Status
+	t.ConsecutiveFailures = from.ConsecutiveFailures
+	t.DisabledReason = from.DisabledReason
+	t.DisabledAt = from.DisabledAt
+	t.PausedBy = from.PausedBy
+	t.PausedAt = from.PausedAt
+	t.PauseReason = from.PauseReason
+	t.ResumeAt = from.ResumeAt
+	t.NextRunAt = from.NextRunAt
+	t.CreatedAt = from.CreatedAt
+	t.UpdatedAt = from.UpdatedAt
+}
+
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+
+		t[k] = mergePatch(t[k], v)
+	}
+
+	return t
+}
+
+// decodeJSON keeps numbers as json.Number so that they
+// are not rounded when encoded again
+func decodeJSON(data []byte, v any) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	return d.Decode(v)
+}
@@ -0,0 +1,57 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	name := "backup"
+	timeout := 60
+	current := &Task{
+		ID:          "01HF7YAT00000000000000000A",
+		Name:        &name,
+		Expression:  "0 * * * *",
+		Timeout:     &timeout,
+		URL:         "https://example.com/backup",
+		HTTPMethod:  "POST",
+		HTTPHeaders: map[string]any{"Authorization": "Bearer x", "X-Env": "prod"},
+		Status:      StatusActive,
+		CreatedAt:   1700000000,
+	}
+
+	t.Run("supplied-fields-only", func(t *testing.T) {
+		patched, err := MergePatch(current, []byte(`{"expression": "*/5 * * * *"}`))
+		assert.NoError(t, err)
+		assert.Equal(t, "*/5 * * * *", patched.Expression)
+		assert.Equal(t, "backup", *patched.Name)
+		assert.Equal(t, current.URL, patched.URL)
+		assert.Equal(t, current.HTTPHeaders, patched.HTTPHeaders)
+		assert.Equal(t, int64(1700000000), patched.CreatedAt)
+		assert.Equal(t, "0 * * * *", current.Expression)
+	})
+
+	t.Run("nested-and-null", func(t *testing.T) {
+		patched, err := MergePatch(current, []byte(`{"http_headers": {"X-Env": null, "X-New": "1"}, "timeout": null}`))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"Authorization": "Bearer x", "X-New": "1"}, patched.HTTPHeaders)
+		assert.Nil(t, patched.Timeout)
+	})
+
+	t.Run("read-only", func(t *testing.T) {
+		patched, err := MergePatch(current, []byte(`{"id": "other", "status": "disabled", "created_at": 0}`))
+		assert.NoError(t, err)
+		assert.Equal(t, current.ID, patched.ID)
+		assert.Equal(t, StatusActive, patched.Status)
+		assert.Equal(t, int64(1700000000), patched.CreatedAt)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := MergePatch(current, []byte(`["expression"]`))
+		assert.ErrorIs(t, err, ErrInvalidPatch)
+
+		_, err = MergePatch(current, []byte(`{"timeout": "soon"}`))
+		assert.ErrorIs(t, err, ErrInvalidPatch)
+	})
+}
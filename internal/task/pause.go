@@ -0,0 +1,191 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const (
+	// PausedReason is the disabled reason of the tasks paused through the API
+	PausedReason = "paused"
+
+	// GroupPausedReason is the disabled reason of the tasks paused along
+	// with their group, resuming the group only resumes those
+	GroupPausedReason = "group paused"
+)
+
+// Pause records who paused tasks, why and until when
+type Pause struct {
+	// PausedBy is the name of the api key used to pause the tasks,
+	// it cannot be set in the request
+	PausedBy *string `json:"-"`
+	Reason   *string `json:"reason"`
+
+	// ResumeAt is the unix time at which the scheduler
+	// resumes the tasks, nil to pause until resumed
+	ResumeAt *int64 `json:"resume_at"`
+}
+
+// pauseQuery disables the tasks matching the condition appended to it,
+// the first six arguments are set by pauseArgs
+const pauseQuery = `
+	UPDATE tasks
+	SET
+		status = $1,
+		disabled_reason = $2,
+		disabled_at = $3,
+		paused_by = $4,
+		paused_at = $3,
+		pause_reason = $5,
+		resume_at = $6,
+		updated_at = $3
+`
+
+func (dao *TaskDAO) pauseArgs(reason string, p Pause) []any {
+	return []any{StatusDisabled, reason, dao.TimeNow(), p.PausedBy, p.Reason, p.ResumeAt}
+}
+
+// ErrNotPausable is returned when pausing a task of an expired tenant
+// or a task disabled for another reason than a pause
+var ErrNotPausable = errors.New("only active and paused tasks can be paused")
+
+// ErrNotPaused is returned when resuming a task that is not paused
+var ErrNotPaused = errors.New("only paused tasks can be resumed")
+
+// Paused reports whether t is disabled by a pause of the task or of
+// its group
+func (t *Task) Paused() bool {
+	if t.Status != StatusDisabled {
+		return false
+	}
+
+	if t.ResumeAt != nil {
+		return true
+	}
+
+	return t.DisabledReason != nil &&
+		(*t.DisabledReason == PausedReason || *t.DisabledReason == GroupPausedReason)
+}
+
+// PauseTask disables the task until it is resumed. It returns nil if
+// the task does not exist and ErrNotPausable if it is neither active
+// nor disabled without a reason or by a pause, the reason a task was
+// disabled for, e.g. failing too many times, is kept.
+func (dao *TaskDAO) PauseTask(ctx context.Context, id string, p Pause) (*Task, error) {
+	db := dao.RW()
+	query := pauseQuery + `
+		WHERE id = $7 AND tenant_id = $8 AND (
+			status = $9 OR (status = $1 AND (
+				disabled_reason IS NULL OR disabled_reason IN ($2, $10)
+				OR resume_at IS NOT NULL
+			))
+		)
+	`
+	res, err := db.ExecContext(ctx, query,
+		append(dao.pauseArgs(PausedReason, p),
+			id, dao.TenantID, StatusActive, GroupPausedReason)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("pause task: %w", err)
+	}
+
+	paused, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("pause task: %w", err)
+	}
+
+	t, err := dao.GetTask(ctx, id)
+	if err != nil || t == nil {
+		return nil, err
+	}
+
+	if paused == 0 {
+		return nil, ErrNotPausable
+	}
+
+	return t, nil
+}
+
+// ResumeTask enables the task if it is paused. It returns nil if the
+// task does not exist and ErrNotPaused if it is not paused, tasks
+// disabled for another reason are enabled with EnableTask.
+func (dao *TaskDAO) ResumeTask(ctx context.Context, id string) (*Task, error) {
+	t, err := dao.GetTask(ctx, id)
+	if err != nil || t == nil {
+		return nil, err
+	}
+
+	if !t.Paused() {
+		return nil, ErrNotPaused
+	}
+
+	if err := dao.enableTask(ctx, t); err != nil {
+		return nil, err
+	}
+
+	return dao.GetTask(ctx, id)
+}
+
+// ResumeDueTasks enables the paused tasks whose resume_at has passed
+// and returns how many tasks were resumed. It is run by the scheduler
+// leader for every tenant that is not expired, regardless of the tenant
+// of the DAO.
+func (dao *TaskDAO) ResumeDueTasks(ctx context.Context) (int64, error) {
+	db := dao.RO()
+	query := `
+		SELECT ` + Columns + `
+		FROM tasks
+		WHERE status = $1 AND resume_at <= $2
+			AND tenant_id IN (SELECT id FROM tenants WHERE status = $3)
+	`
+	rows, err := db.QueryContext(ctx, query, StatusDisabled, dao.TimeNow(), StatusActive)
+	if err != nil {
+		return 0, fmt.Errorf("get due tasks: %w", err)
+	}
+
+	return dao.enableTasks(ctx, rows)
+}
+
+// enableTasks enables the tasks selected with Columns. Every task gets
+// its own next_run_at, so they are enabled one by one.
+func (dao *TaskDAO) enableTasks(ctx context.Context, rows *sql.Rows) (int64, error) {
+	defer rows.Close()
+
+	tasks := []Task{}
+	for rows.Next() {
+		t, err := Scan(rows)
+		if err != nil {
+			return 0, fmt.Errorf("scan row: %w", err)
+		}
+
+		tasks = append(tasks, *t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("rows iteration: %w", err)
+	}
+	rows.Close()
+
+	var enabled int64
+	for _, t := range tasks {
+		if err := dao.enableTask(ctx, &t); err != nil {
+			return enabled, err
+		}
+		enabled++
+	}
+
+	return enabled, nil
+}
+
+var errResumeAtPast = errors.New("resume_at must be in the future")
+
+// Validate checks the pause requested at the given time
+func (p *Pause) Validate(now int64) error {
+	if p.ResumeAt != nil && *p.ResumeAt <= now {
+		return errResumeAtPast
+	}
+
+	return nil
+}
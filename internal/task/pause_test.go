@@ -0,0 +1,131 @@
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stuckinforloop/ticker/database"
+)
+
+func TestPause(t *testing.T) {
+	db := database.NewTestDB(t)
+	now := int64(1700000000)
+	taskDAO := newTestTaskDAO(t, db, &now)
+
+	t.Run("pause-resume", func(t *testing.T) {
+		ctx := context.Background()
+		tk := createTestTask(t, taskDAO, nil)
+
+		paused, err := taskDAO.PauseTask(ctx, tk.ID, Pause{
+			PausedBy: typePtr("ops"),
+			Reason:   typePtr("maintenance"),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, StatusDisabled, paused.Status)
+		assert.Equal(t, PausedReason, *paused.DisabledReason)
+		assert.Equal(t, "ops", *paused.PausedBy)
+		assert.Equal(t, "maintenance", *paused.PauseReason)
+		assert.Equal(t, now, *paused.PausedAt)
+
+		resumed, err := taskDAO.ResumeTask(ctx, tk.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, StatusActive, resumed.Status)
+		assert.Nil(t, resumed.DisabledReason)
+		assert.Nil(t, resumed.PausedBy)
+		assert.Nil(t, resumed.PauseReason)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		paused, err := taskDAO.PauseTask(context.Background(), "01J00000000000000000000000", Pause{})
+		assert.NoError(t, err)
+		assert.Nil(t, paused)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		ctx := context.Background()
+		tk := createTestTask(t, taskDAO, nil)
+
+		_, err := db.ExecContext(ctx, `UPDATE tasks SET status = $1 WHERE id = $2`, StatusExpired, tk.ID)
+		assert.NoError(t, err)
+
+		_, err = taskDAO.PauseTask(ctx, tk.ID, Pause{})
+		assert.ErrorIs(t, err, ErrNotPausable)
+
+		tk, err = taskDAO.GetTask(ctx, tk.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, StatusExpired, tk.Status)
+	})
+
+	t.Run("failure-disabled", func(t *testing.T) {
+		ctx := context.Background()
+		tk := createTestTask(t, taskDAO, nil)
+
+		_, err := db.ExecContext(ctx,
+			`UPDATE tasks SET status = $1, disabled_reason = $2 WHERE id = $3`,
+			StatusDisabled, "failed 3 times in a row", tk.ID)
+		assert.NoError(t, err)
+
+		_, err = taskDAO.PauseTask(ctx, tk.ID, Pause{})
+		assert.ErrorIs(t, err, ErrNotPausable)
+
+		_, err = taskDAO.ResumeTask(ctx, tk.ID)
+		assert.ErrorIs(t, err, ErrNotPaused)
+
+		tk, err = taskDAO.GetTask(ctx, tk.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, StatusDisabled, tk.Status)
+		assert.Equal(t, "failed 3 times in a row", *tk.DisabledReason)
+	})
+
+	t.Run("resume-due", func(t *testing.T) {
+		ctx := context.Background()
+		due := createTestTask(t, taskDAO, nil)
+		later := createTestTask(t, taskDAO, nil)
+		untilResumed := createTestTask(t, taskDAO, nil)
+
+		for id, resumeAt := range map[string]*int64{
+			due.ID:          typePtr(now + 60),
+			later.ID:        typePtr(now + 3600),
+			untilResumed.ID: nil,
+		} {
+			_, err := taskDAO.PauseTask(ctx, id, Pause{ResumeAt: resumeAt})
+			assert.NoError(t, err)
+		}
+
+		now += 60
+		defer func() { now -= 60 }()
+
+		resumed, err := taskDAO.ResumeDueTasks(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), resumed)
+
+		for id, status := range map[string]Status{
+			due.ID:          StatusActive,
+			later.ID:        StatusDisabled,
+			untilResumed.ID: StatusDisabled,
+		} {
+			tk, err := taskDAO.GetTask(ctx, id)
+			assert.NoError(t, err)
+			assert.Equal(t, status, tk.Status)
+		}
+	})
+}
+
+func TestPaused(t *testing.T) {
+	for name, tc := range map[string]struct {
+		task   Task
+		paused bool
+	}{
+		"active":       {Task{Status: StatusActive, DisabledReason: typePtr(PausedReason)}, false},
+		"paused":       {Task{Status: StatusDisabled, DisabledReason: typePtr(PausedReason)}, true},
+		"group-paused": {Task{Status: StatusDisabled, DisabledReason: typePtr(GroupPausedReason)}, true},
+		"resume-at":    {Task{Status: StatusDisabled, ResumeAt: typePtr(int64(1))}, true},
+		"disabled":     {Task{Status: StatusDisabled}, false},
+		"failing":      {Task{Status: StatusDisabled, DisabledReason: typePtr("failed 3 times in a row")}, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.paused, tc.task.Paused())
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stuckinforloop/ticker/internal/quota"
+)
+
+// fillDefaultsWithin fills the defaults of t like fillDefaults, without
+// going over the max_timeout quota
+func (dao *TaskDAO) fillDefaultsWithin(t *Task, q quota.Quotas) {
+	if t.Timeout == nil && q.MaxTimeout > 0 {
+		t.Timeout = typePtr(min(defaultTimeout, q.MaxTimeout))
+	}
+
+	dao.fillDefaults(t)
+}
+
+// checkQuotas checks the timeout and schedule of t against the quotas
+// of the tenant, defaults must have been filled
+func (dao *TaskDAO) checkQuotas(t *Task, q quota.Quotas) error {
+	if err := q.CheckTimeout(*t.Timeout); err != nil {
+		return err
+	}
+
+	if q.MinInterval == 0 {
+		return nil
+	}
+
+	interval, err := t.ShortestInterval(time.Unix(dao.TimeNow(), 0))
+	if err != nil {
+		return fmt.Errorf("interval: %w", err)
+	}
+
+	return q.CheckInterval(interval)
+}
+
+// checkTaskCount checks that the tenant may have one more task. The
+// count is taken under a lock held until tx ends, so that concurrent
+// creations cannot go over the quota.
+func (dao *TaskDAO) checkTaskCount(ctx context.Context, tx *sql.Tx, q quota.Quotas) error {
+	if q.MaxTasks == 0 {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`SELECT pg_advisory_xact_lock(hashtext('tasks/' || $1))`, dao.TenantID,
+	); err != nil {
+		return fmt.Errorf("lock tenant %s: %w", dao.TenantID, err)
+	}
+
+	var count int64
+	query := `
+		SELECT COUNT(*)
+		FROM tasks
+		WHERE tenant_id = $1
+	`
+	if err := tx.QueryRowContext(ctx, query, dao.TenantID).Scan(&count); err != nil {
+		return fmt.Errorf("count tasks: %w", err)
+	}
+
+	return q.CheckTasks(count + 1)
+}
+
+// CountTasks returns the number of tasks of the tenant
+func (dao *TaskDAO) CountTasks(ctx context.Context) (int64, error) {
+	db := dao.RO()
+	query := `
+		SELECT COUNT(*)
+		FROM tasks
+		WHERE tenant_id = $1
+	`
+	var count int64
+	if err := db.QueryRowContext(ctx, query, dao.TenantID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count tasks: %w", err)
+	}
+
+	return count, nil
+}
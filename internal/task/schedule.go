@@ -0,0 +1,177 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gitploy-io/cronexpr"
+)
+
+// Location returns the time.Location of the timezone. The legacy
+// "utc" value is accepted as an alias of UTC.
+func (tz Timezone) Location() (*time.Location, error) {
+	switch {
+	case tz == "" || strings.EqualFold(string(tz), string(UTC)):
+		return time.UTC, nil
+	case tz == "Local":
+		return nil, errors.New("unknown time zone Local")
+	}
+
+	return time.LoadLocation(string(tz))
+}
+
+// NextRun returns the first run of the task strictly after the given time.
+func (t *Task) NextRun(after time.Time) (time.Time, error) {
+	return NextRun(t.Expression, t.Timezone, after)
+}
+
+// NextRun returns the first time matching expression strictly after the
+// given time, evaluating the expression in the timezone tz.
+//
+// Daylight saving transitions follow the policy of Vixie cron:
+//   - expressions with a wildcard hour field ("*", "*/2", "0-23/2") run
+//     at every matching instant, so nothing runs in a skipped hour and
+//     a repeated hour is run twice.
+//   - expressions with fixed hours run at wall-clock times. A run that
+//     falls in a skipped hour happens when the clocks change and a run in
+//     a repeated hour happens only once, in its first occurrence.
+func NextRun(expression string, tz Timezone, after time.Time) (time.Time, error) {
+	loc, err := tz.Location()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("load location: %w", err)
+	}
+
+	schedule, err := cronexpr.Parse(expression)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse expression: %w", err)
+	}
+
+	if hasWildcardHour(expression) {
+		schedule.Location = loc
+		next := schedule.Next(after)
+		if next.IsZero() {
+			return time.Time{}, errors.New("expression has no upcoming run")
+		}
+
+		return next, nil
+	}
+
+	// schedule is evaluated in UTC which has no transitions,
+	// so it is used to step through wall-clock times
+	wall := wallClock(after.In(loc))
+	for {
+		wall = schedule.Next(wall)
+		if wall.IsZero() {
+			return time.Time{}, errors.New("expression has no upcoming run")
+		}
+
+		// the first occurrence of a repeated wall-clock time
+		// may already be in the past
+		if next := fromWallClock(wall, loc); next.After(after) {
+			return next, nil
+		}
+	}
+}
+
+// intervalSamples is the number of upcoming runs looked at to find
+// the shortest interval of a schedule
+const intervalSamples = 100
+
+// ShortestInterval returns the shortest number of seconds between two
+// of the upcoming runs of the task after the given time
+func (t *Task) ShortestInterval(after time.Time) (int64, error) {
+	prev, err := t.NextRun(after)
+	if err != nil {
+		return 0, err
+	}
+
+	shortest := int64(-1)
+	for i := 1; i < intervalSamples; i++ {
+		next, err := t.NextRun(prev)
+		if err != nil {
+			// a schedule may run out of upcoming runs, e.g. a fixed year
+			break
+		}
+
+		if interval := next.Unix() - prev.Unix(); shortest < 0 || interval < shortest {
+			shortest = interval
+		}
+		prev = next
+	}
+
+	// a single run has no interval
+	if shortest < 0 {
+		return math.MaxInt64, nil
+	}
+
+	return shortest, nil
+}
+
+// hasWildcardHour reports whether the hour field of the expression is a
+// single range over the whole day, with an optional step: "*", "*/2",
+// "0-23", "0-23/2" or "0/2". Expressions of six or more fields start
+// with a seconds field.
+func hasWildcardHour(expression string) bool {
+	fields := strings.Fields(expression)
+	hourField := 1
+	if len(fields) >= 6 {
+		hourField = 2
+	}
+
+	if len(fields) <= hourField || strings.Contains(fields[hourField], ",") {
+		return false
+	}
+
+	span, _, hasStep := strings.Cut(fields[hourField], "/")
+	if span == "*" {
+		return true
+	}
+
+	low, high, isRange := strings.Cut(span, "-")
+	if first, err := strconv.Atoi(low); err != nil || first != 0 {
+		return false
+	}
+
+	// "0/2" runs from 0 to the last hour
+	if !isRange {
+		return hasStep
+	}
+
+	last, err := strconv.Atoi(high)
+	return err == nil && last >= 23
+}
+
+// wallClock returns the wall-clock time of t expressed in UTC
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+// fromWallClock returns the instant at which the wall-clock time is read
+// in loc. Repeated times resolve to their first occurrence and times in a
+// skipped hour to the instant the clocks change. Zones are assumed not to
+// change their offset twice within a day.
+func fromWallClock(wall time.Time, loc *time.Location) time.Time {
+	local := wall.Unix()
+	_, before := time.Unix(local-secondsPerDay, 0).In(loc).Zone()
+	_, after := time.Unix(local+secondsPerDay, 0).In(loc).Zone()
+
+	// read at offset o, the wall-clock time is the instant local-o. It
+	// exists if loc has that offset then, the larger offset comes first.
+	for _, o := range []int{max(before, after), min(before, after)} {
+		t := time.Unix(local-int64(o), 0).In(loc)
+		if _, offset := t.Zone(); offset == o {
+			return t
+		}
+	}
+
+	// the time was skipped, read at the offset before the transition
+	// it falls after it
+	start, _ := time.Unix(local-int64(before), 0).In(loc).ZoneBounds()
+	return start
+}
+
+const secondsPerDay = 24 * 60 * 60
@@ -0,0 +1,185 @@
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextRun(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	t.Run("timezone", func(t *testing.T) {
+		after := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+		next, err := NextRun("30 9 * * *", "Asia/Kolkata", after)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 7, 1, 4, 0, 0, 0, time.UTC), next.UTC())
+
+		next, err = NextRun("30 9 * * *", "utc", after)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 7, 1, 9, 30, 0, 0, time.UTC), next.UTC())
+
+		_, err = NextRun("30 9 * * *", "Mars/Olympus_Mons", after)
+		assert.Error(t, err)
+	})
+
+	t.Run("skipped-hour-fixed", func(t *testing.T) {
+		// clocks jump from 02:00 EST to 03:00 EDT
+		after := time.Date(2024, 3, 10, 1, 0, 0, 0, newYork)
+
+		next, err := NextRun("30 2 * * *", "America/New_York", after)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 3, 10, 3, 0, 0, 0, newYork), next)
+
+		next, err = NextRun("30 2 * * *", "America/New_York", next)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 3, 11, 2, 30, 0, 0, newYork), next)
+	})
+
+	t.Run("skipped-hour-wildcard", func(t *testing.T) {
+		after := time.Date(2024, 3, 10, 1, 45, 0, 0, newYork)
+
+		next, err := NextRun("*/30 * * * *", "America/New_York", after)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 3, 10, 3, 0, 0, 0, newYork), next)
+	})
+
+	t.Run("repeated-hour-fixed", func(t *testing.T) {
+		// clocks fall back from 02:00 EDT to 01:00 EST
+		after := time.Date(2024, 11, 3, 0, 0, 0, 0, newYork)
+
+		first, err := NextRun("30 1 * * *", "America/New_York", after)
+		assert.NoError(t, err)
+		assert.Equal(t, "EDT", zoneName(first))
+
+		next, err := NextRun("30 1 * * *", "America/New_York", first)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 11, 4, 1, 30, 0, 0, newYork), next)
+	})
+
+	t.Run("repeated-hour-wildcard", func(t *testing.T) {
+		after := time.Date(2024, 11, 3, 0, 0, 0, 0, newYork)
+
+		first, err := NextRun("30 * * * *", "America/New_York", after.Add(time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, "EDT", zoneName(first))
+
+		second, err := NextRun("30 * * * *", "America/New_York", first)
+		assert.NoError(t, err)
+		assert.Equal(t, "EST", zoneName(second))
+		assert.Equal(t, time.Hour, second.Sub(first))
+	})
+}
+
+func TestNextRunEastOfUTC(t *testing.T) {
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	assert.NoError(t, err)
+	lordHowe, err := time.LoadLocation("Australia/Lord_Howe")
+	assert.NoError(t, err)
+
+	for _, tc := range []struct {
+		name       string
+		expression string
+		tz         Timezone
+		after      time.Time
+		next       time.Time
+	}{
+		// clocks jump from 02:00 AEST to 03:00 AEDT
+		{
+			"sydney-skipped-hour", "30 2 * * *", "Australia/Sydney",
+			time.Date(2024, 10, 5, 12, 0, 0, 0, time.UTC),
+			time.Date(2024, 10, 6, 3, 0, 0, 0, sydney),
+		},
+		{
+			"sydney-after-skipped-hour", "30 2 * * *", "Australia/Sydney",
+			time.Date(2024, 10, 6, 3, 0, 0, 0, sydney),
+			time.Date(2024, 10, 7, 2, 30, 0, 0, sydney),
+		},
+		// clocks fall back from 03:00 AEDT to 02:00 AEST
+		{
+			"sydney-repeated-hour", "30 2 * * *", "Australia/Sydney",
+			time.Date(2024, 4, 6, 12, 0, 0, 0, time.UTC),
+			time.Date(2024, 4, 6, 15, 30, 0, 0, time.UTC),
+		},
+		{
+			"sydney-after-repeated-hour", "30 2 * * *", "Australia/Sydney",
+			time.Date(2024, 4, 6, 15, 30, 0, 0, time.UTC),
+			time.Date(2024, 4, 8, 2, 30, 0, 0, sydney),
+		},
+		// clocks jump half an hour from 02:00 to 02:30
+		{
+			"lord-howe-skipped-half-hour", "15 2 * * *", "Australia/Lord_Howe",
+			time.Date(2024, 10, 5, 12, 0, 0, 0, time.UTC),
+			time.Date(2024, 10, 6, 2, 30, 0, 0, lordHowe),
+		},
+		{
+			"lord-howe-after-skipped-half-hour", "45 2 * * *", "Australia/Lord_Howe",
+			time.Date(2024, 10, 5, 12, 0, 0, 0, time.UTC),
+			time.Date(2024, 10, 6, 2, 45, 0, 0, lordHowe),
+		},
+		// clocks fall back half an hour from 02:00 to 01:30
+		{
+			"lord-howe-repeated-half-hour", "45 1 * * *", "Australia/Lord_Howe",
+			time.Date(2024, 4, 6, 12, 0, 0, 0, time.UTC),
+			time.Date(2024, 4, 6, 14, 45, 0, 0, time.UTC),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			next, err := NextRun(tc.expression, tc.tz, tc.after)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.next.UTC(), next.UTC())
+		})
+	}
+}
+
+func TestHasWildcardHour(t *testing.T) {
+	for expression, wildcard := range map[string]bool{
+		"0 * * * *":      true,
+		"0 */2 * * *":    true,
+		"0 0-23 * * *":   true,
+		"0 0-23/2 * * *": true,
+		"0 0/2 * * *":    true,
+		"0 2 * * *":      false,
+		"0 1-23/2 * * *": false,
+		"0 0-12 * * *":   false,
+		"0 */2,5 * * *":  false,
+		// the hour of six fields expressions follows the seconds
+		"0 0 * * * *":      true,
+		"0 0 0-23/2 * * *": true,
+		"0 30 2 * * *":     false,
+		"0 * 2 * * *":      false,
+	} {
+		t.Run(expression, func(t *testing.T) {
+			assert.Equal(t, wildcard, hasWildcardHour(expression))
+		})
+	}
+}
+
+func zoneName(t time.Time) string {
+	name, _ := t.Zone()
+	return name
+}
+
+func TestShortestInterval(t *testing.T) {
+	after := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		expression string
+		interval   int64
+	}{
+		{"*/5 * * * *", 300},
+		{"0 9,17 * * *", 8 * 3600},
+		{"0 9 * * 1-5", 24 * 3600},
+		{"0 9,9-10 1 * *", 3600},
+	} {
+		t.Run(tc.expression, func(t *testing.T) {
+			task := &Task{Expression: tc.expression, Timezone: UTC}
+			interval, err := task.ShortestInterval(after)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.interval, interval)
+		})
+	}
+}
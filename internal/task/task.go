@@ -6,53 +6,172 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
+
+	"github.com/stuckinforloop/ticker/internal/quota"
 )
 
 type Timezone string
 type Status string
+type Backoff string
+type MisfirePolicy string
+type ConcurrencyPolicy string
 
 const (
-	UTC Timezone = "utc"
+	UTC Timezone = "UTC"
 
 	StatusActive   Status = "active"
 	StatusDisabled Status = "disabled"
-	StatusExpired  Status = "expired" // when account is expired
+	StatusExpired  Status = "expired" // when the tenant is expired
+
+	BackoffFixed       Backoff = "fixed"
+	BackoffExponential Backoff = "exponential"
+
+	MisfireSkip     MisfirePolicy = "skip"      // record missed runs only
+	MisfireFireOnce MisfirePolicy = "fire_once" // fire the latest missed run
+	MisfireFireAll  MisfirePolicy = "fire_all"  // fire up to misfire_limit missed runs
+)
+
+const (
+	ConcurrencyAllow   ConcurrencyPolicy = "allow"   // wait for a free instance
+	ConcurrencyForbid  ConcurrencyPolicy = "forbid"  // skip the run
+	ConcurrencyReplace ConcurrencyPolicy = "replace" // cancel the oldest running execution
 )
 
+// MaxMisfireLimit caps the number of missed runs fired by fire_all
+const MaxMisfireLimit = 100
+
 type Task struct {
-	ID               string         `json:"id"`
-	Name             *string        `json:"name"`
-	GroupID          *string        `json:"group_id"`
-	Expression       string         `json:"expression"`
-	Timezone         Timezone       `json:"timezone"`
-	Timeout          *int           `json:"timeout"`
-	Instances        *int           `json:"instances"`
-	URL              string         `json:"url"`
-	HTTPMethod       string         `json:"http_method"`
-	HTTPHeaders      map[string]any `json:"http_headers"`
-	PostData         map[string]any `json:"post_data"`
-	RetryAfter       *int           `json:"retry_after"`
-	FailureThreshold *int           `json:"failure_threshold"`
-	Notify           bool           `json:"notify"`
-	NotifyEvery      *int           `json:"notify_every"`
-	Status           Status         `json:"status"`
-	CreatedAt        int64          `json:"created_at"`
-	UpdatedAt        int64          `json:"updated_at"`
+	ID                  string            `json:"id"`
+	TenantID            string            `json:"tenant_id"`
+	Name                *string           `json:"name"`
+	GroupID             *string           `json:"group_id"`
+	Expression          string            `json:"expression"`
+	Timezone            Timezone          `json:"timezone"`
+	Timeout             *int              `json:"timeout"`
+	Instances           *int              `json:"instances"`
+	Type                Type              `json:"type"`
+	URL                 string            `json:"url"`
+	HTTPMethod          string            `json:"http_method"`
+	HTTPHeaders         map[string]any    `json:"http_headers"`
+	PostData            map[string]any    `json:"post_data"`
+	Shell               *ShellConfig      `json:"shell"`
+	SQL                 *SQLConfig        `json:"sql"`
+	RetryAfter          *int              `json:"retry_after"`
+	RetryBackoff        Backoff           `json:"retry_backoff"`
+	MaxAttempts         *int              `json:"max_attempts"`
+	FailureThreshold    *int              `json:"failure_threshold"`
+	Notify              bool              `json:"notify"`
+	NotifyEvery         *int              `json:"notify_every"`
+	MisfirePolicy       MisfirePolicy     `json:"misfire_policy"`
+	MisfireLimit        *int              `json:"misfire_limit"`
+	ConcurrencyPolicy   ConcurrencyPolicy `json:"concurrency_policy"`
+	SuccessCriteria     *SuccessCriteria  `json:"success_criteria"`
+	Status              Status            `json:"status"`
+	ConsecutiveFailures int               `json:"consecutive_failures"`
+	DisabledReason      *string           `json:"disabled_reason"`
+	DisabledAt          *int64            `json:"disabled_at"`
+	PausedBy            *string           `json:"paused_by"`
+	PausedAt            *int64            `json:"paused_at"`
+	PauseReason         *string           `json:"pause_reason"`
+	ResumeAt            *int64            `json:"resume_at"`
+	NextRunAt           *int64            `json:"next_run_at"`
+	CreatedAt           int64             `json:"created_at"`
+	UpdatedAt           int64             `json:"updated_at"`
 }
 
 type Group struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID        string `json:"id"`
+	TenantID  string `json:"tenant_id"`
+	Name      string `json:"name"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// Columns lists the columns of the tasks table in the order
+// expected by Scan.
+const Columns = `
+	id, tenant_id, name, group_id, expression, timezone,
+	timeout, instances, url, http_method, http_headers,
+	post_data, retry_after, retry_backoff, max_attempts,
+	failure_threshold, notify, notify_every, misfire_policy,
+	misfire_limit, concurrency_policy, success_criteria, type,
+	config, status, consecutive_failures, disabled_reason,
+	disabled_at, paused_by, paused_at, pause_reason, resume_at,
+	next_run_at, created_at, updated_at
+`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// Scan reads a row selected with Columns into a Task.
+func Scan(row scanner) (*Task, error) {
+	t := Task{}
+	headers := []byte{}
+	postData := []byte{}
+	criteria := []byte{}
+	config := []byte{}
+	if err := row.Scan(
+		&t.ID, &t.TenantID, &t.Name, &t.GroupID, &t.Expression, &t.Timezone,
+		&t.Timeout, &t.Instances, &t.URL, &t.HTTPMethod, &headers,
+		&postData, &t.RetryAfter, &t.RetryBackoff, &t.MaxAttempts,
+		&t.FailureThreshold, &t.Notify, &t.NotifyEvery, &t.MisfirePolicy,
+		&t.MisfireLimit, &t.ConcurrencyPolicy, &criteria, &t.Type,
+		&config, &t.Status, &t.ConsecutiveFailures, &t.DisabledReason,
+		&t.DisabledAt, &t.PausedBy, &t.PausedAt, &t.PauseReason, &t.ResumeAt,
+		&t.NextRunAt, &t.CreatedAt, &t.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(headers, &t.HTTPHeaders); err != nil {
+		return nil, fmt.Errorf("unmarshal http headers: %w", err)
+	}
+
+	if err := json.Unmarshal(postData, &t.PostData); err != nil {
+		return nil, fmt.Errorf("unmarshal post data: %w", err)
+	}
+
+	if criteria != nil {
+		if err := json.Unmarshal(criteria, &t.SuccessCriteria); err != nil {
+			return nil, fmt.Errorf("unmarshal success criteria: %w", err)
+		}
+	}
+
+	if err := t.setConfig(config); err != nil {
+		return nil, fmt.Errorf("unmarshal %s config: %w", t.Type, err)
+	}
+
+	return &t, nil
 }
 
+// CreateTask stores a new task of the tenant. It returns a
+// quota.ExceededError if the task goes over the quotas of the tenant.
 func (dao *TaskDAO) CreateTask(ctx context.Context, t *Task) (*Task, error) {
+	q, err := quota.NewQuotaDAO(dao.DAO).GetQuotas(ctx, dao.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
 	// fill default values if not provided
-	dao.fillDefaults(t)
+	dao.fillDefaultsWithin(t, q)
 
+	if err := dao.checkQuotas(t, q); err != nil {
+		return nil, err
+	}
+
+	t.TenantID = dao.TenantID
 	t.Status = StatusActive
 	t.CreatedAt = dao.TimeNow()
 	t.UpdatedAt = dao.TimeNow()
 
+	nextRunAt, err := dao.nextRunAt(t)
+	if err != nil {
+		return nil, err
+	}
+	t.NextRunAt = nextRunAt
+
 	headers, err := json.Marshal(t.HTTPHeaders)
 	if err != nil {
 		return nil, fmt.Errorf("marshal http headers: %w", err)
@@ -63,148 +182,135 @@ func (dao *TaskDAO) CreateTask(ctx context.Context, t *Task) (*Task, error) {
 		return nil, fmt.Errorf("marshal post data: %w", err)
 	}
 
-	db := dao.RW()
-	query := `
-		INSERT INTO tasks (
-			id, name, group_id, expression, timezone,
-			timeout, instances, url, http_method, http_headers,
-			post_data, retry_after, failure_threshold, notify,
-			notify_every, status, created_at, updated_at
-		) Values (
-			$1, $2, $3, $4, $5,
-			$6, $7, $8, $9, $10,
-			$11, $12, $13, $14,
-			$15, $16, $17, $18
-		)
-	`
-
-	if _, err := db.ExecContext(ctx, query,
-		t.ID, t.Name, t.GroupID, t.Expression, t.Timezone,
-		t.Timeout, t.Instances, t.URL, t.HTTPMethod, &headers,
-		&postData, t.RetryAfter, t.FailureThreshold, t.Notify,
-		t.NotifyEvery, t.Status, t.CreatedAt, t.UpdatedAt,
-	); err != nil {
-		return nil, fmt.Errorf("create task: %w", err)
+	criteria, err := json.Marshal(t.SuccessCriteria)
+	if err != nil {
+		return nil, fmt.Errorf("marshal success criteria: %w", err)
 	}
 
-	return t, nil
-}
+	config, err := t.config()
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s config: %w", t.Type, err)
+	}
 
-func (dao *TaskDAO) GetTasks(ctx context.Context) ([]Task, error) {
-	db := dao.RO()
 	query := `
-		SELECT 
-			id, name, group_id, expression, timezone,
-			timeout, instances, url, http_method, http_headers,
-			post_data, retry_after, failure_threshold, notify,
-			notify_every, status, created_at, updated_at
-		FROM tasks
+		INSERT INTO tasks (` + Columns + `) Values (
+			$1, $2, $3, $4, $5, $6,
+			$7, $8, $9, $10, $11,
+			$12, $13, $14, $15,
+			$16, $17, $18, $19,
+			$20, $21, $22, $23,
+			$24, $25, $26, $27,
+			$28, $29, $30, $31, $32,
+			$33, $34, $35
+		)
 	`
-	rows, err := db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("get tasks: %w", err)
-	}
-	defer rows.Close()
-
-	tasks := []Task{}
-	for rows.Next() {
-		t := Task{}
-		headers := []byte{}
-		postData := []byte{}
-		if err := rows.Scan(
-			&t.ID, &t.Name, &t.GroupID, &t.Expression,
-			&t.Timezone, &t.Timeout, &t.Instances, &t.URL,
-			&t.HTTPMethod, &headers, &postData,
-			&t.RetryAfter, &t.FailureThreshold, &t.Notify,
-			&t.NotifyEvery, &t.Status, &t.CreatedAt, &t.UpdatedAt,
-		); err != nil {
-			return nil, fmt.Errorf("scan row: %w", err)
-		}
 
-		if err := json.Unmarshal(headers, &t.HTTPHeaders); err != nil {
-			return nil, fmt.Errorf("unmarshal http headers: %w", err)
+	if err := dao.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := dao.checkTaskCount(ctx, tx, q); err != nil {
+			return err
 		}
 
-		if err := json.Unmarshal(postData, &t.PostData); err != nil {
-			return nil, fmt.Errorf("unmarshal post data: %w", err)
+		if _, err := tx.ExecContext(ctx, query,
+			t.ID, t.TenantID, t.Name, t.GroupID, t.Expression, t.Timezone,
+			t.Timeout, t.Instances, t.URL, t.HTTPMethod, &headers,
+			&postData, t.RetryAfter, t.RetryBackoff, t.MaxAttempts,
+			t.FailureThreshold, t.Notify, t.NotifyEvery, t.MisfirePolicy,
+			t.MisfireLimit, t.ConcurrencyPolicy, &criteria, t.Type,
+			&config, t.Status, t.ConsecutiveFailures, t.DisabledReason,
+			t.DisabledAt, t.PausedBy, t.PausedAt, t.PauseReason, t.ResumeAt,
+			t.NextRunAt, t.CreatedAt, t.UpdatedAt,
+		); err != nil {
+			return fmt.Errorf("create task: %w", err)
 		}
 
-		tasks = append(tasks, t)
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 
-	if rows.Err() != nil {
-		return nil, fmt.Errorf("rows iteration: %w", err)
-	}
-
-	return tasks, nil
+	return t, nil
 }
 
 func (dao *TaskDAO) GetTask(ctx context.Context, id string) (*Task, error) {
 	db := dao.RO()
 	query := `
-		SELECT 
-			id, name, group_id, expression, timezone,
-			timeout, instances, url, http_method, http_headers,
-			post_data, retry_after, failure_threshold, notify,
-			notify_every, status, created_at, updated_at
+		SELECT ` + Columns + `
 		FROM tasks
-		WHERE id = $1
+		WHERE id = $1 AND tenant_id = $2
 	`
-	t := Task{}
-	headers := []byte{}
-	postData := []byte{}
-	if err := db.QueryRowContext(ctx, query, id).Scan(
-		&t.ID, &t.Name, &t.GroupID, &t.Expression,
-		&t.Timezone, &t.Timeout, &t.Instances, &t.URL,
-		&t.HTTPMethod, &headers, &postData,
-		&t.RetryAfter, &t.FailureThreshold, &t.Notify,
-		&t.NotifyEvery, &t.Status, &t.CreatedAt, &t.UpdatedAt,
-	); err != nil {
+	t, err := Scan(db.QueryRowContext(ctx, query, id, dao.TenantID))
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
-	}
 
-	if err := json.Unmarshal(headers, &t.HTTPHeaders); err != nil {
-		return nil, fmt.Errorf("unmarshal http headers: %w", err)
+		return nil, fmt.Errorf("get task: %w", err)
 	}
 
-	if err := json.Unmarshal(postData, &t.PostData); err != nil {
-		return nil, fmt.Errorf("unmarshal post_data: %w", err)
-	}
-
-	return &t, nil
+	return t, nil
 }
 
 func (dao *TaskDAO) DeleteTask(ctx context.Context, id string) error {
 	db := dao.RW()
 	query := `
 		DELETE FROM tasks
-		WHERE id = $1
+		WHERE id = $1 AND tenant_id = $2
 	`
-	if _, err := db.ExecContext(ctx, query, id); err != nil {
+	if _, err := db.ExecContext(ctx, query, id, dao.TenantID); err != nil {
 		return fmt.Errorf("delete task: %w", err)
 	}
 
 	return nil
 }
 
-func (dao *TaskDAO) UpdateTask(ctx context.Context, t *Task) error {
+// UpdateTask writes the columns of t set through the API, fields left
+// empty are set to their default. The status of the task is left as is,
+// it may have been changed by the scheduler or a pause since t was read.
+// It returns the updated task, nil if it does not exist, and a
+// quota.ExceededError if the task goes over the quotas of the tenant.
+func (dao *TaskDAO) UpdateTask(ctx context.Context, t *Task) (*Task, error) {
+	q, err := quota.NewQuotaDAO(dao.DAO).GetQuotas(ctx, dao.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	dao.fillDefaultsWithin(t, q)
+
+	if err := dao.checkQuotas(t, q); err != nil {
+		return nil, err
+	}
+
 	headers, err := json.Marshal(t.HTTPHeaders)
 	if err != nil {
-		return fmt.Errorf("marshal http headers: %w", err)
+		return nil, fmt.Errorf("marshal http headers: %w", err)
 	}
 
 	postData, err := json.Marshal(t.PostData)
 	if err != nil {
-		return fmt.Errorf("marshal post data: %w", err)
+		return nil, fmt.Errorf("marshal post data: %w", err)
+	}
+
+	criteria, err := json.Marshal(t.SuccessCriteria)
+	if err != nil {
+		return nil, fmt.Errorf("marshal success criteria: %w", err)
+	}
+
+	config, err := t.config()
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s config: %w", t.Type, err)
 	}
 
 	t.UpdatedAt = dao.TimeNow()
 
+	nextRunAt, err := dao.nextRunAt(t)
+	if err != nil {
+		return nil, err
+	}
+	t.NextRunAt = nextRunAt
+
 	db := dao.RW()
 	query := `
-		UPDATE tasks 
+		UPDATE tasks
 		SET
 			name = $1,
 			group_id = $2,
@@ -217,15 +323,23 @@ func (dao *TaskDAO) UpdateTask(ctx context.Context, t *Task) error {
 			http_headers = $9::jsonb,
 			post_data = $10::jsonb,
 			retry_after = $11,
-			failure_threshold = $12,
-			notify = $13,
-			notify_every = $14,
-			status = $15,
-			created_at = $16,
-			updated_at = $17
-		WHERE id = $18
+			retry_backoff = $12,
+			max_attempts = $13,
+			failure_threshold = $14,
+			notify = $15,
+			notify_every = $16,
+			misfire_policy = $17,
+			misfire_limit = $18,
+			concurrency_policy = $19,
+			success_criteria = $20::jsonb,
+			type = $21,
+			config = $22::jsonb,
+			next_run_at = $23,
+			updated_at = $24
+		WHERE id = $25 AND tenant_id = $26
+		RETURNING ` + Columns + `
 	`
-	if _, err := db.ExecContext(ctx, query,
+	updated, err := Scan(db.QueryRowContext(ctx, query,
 		t.Name,
 		t.GroupID,
 		t.Expression,
@@ -237,16 +351,86 @@ func (dao *TaskDAO) UpdateTask(ctx context.Context, t *Task) error {
 		headers,
 		postData,
 		t.RetryAfter,
+		t.RetryBackoff,
+		t.MaxAttempts,
 		t.FailureThreshold,
 		t.Notify,
 		t.NotifyEvery,
-		t.Status,
-		t.CreatedAt,
+		t.MisfirePolicy,
+		t.MisfireLimit,
+		t.ConcurrencyPolicy,
+		criteria,
+		t.Type,
+		config,
+		t.NextRunAt,
 		t.UpdatedAt,
 		t.ID,
+		dao.TenantID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("update task: %w", err)
+	}
+
+	return updated, nil
+}
+
+// EnableTask re-activates a disabled task and resets its failure count.
+// It returns nil if the task does not exist.
+func (dao *TaskDAO) EnableTask(ctx context.Context, id string) (*Task, error) {
+	t, err := dao.GetTask(ctx, id)
+	if err != nil || t == nil {
+		return nil, err
+	}
+
+	if err := dao.enableTask(ctx, t); err != nil {
+		return nil, err
+	}
+
+	return dao.GetTask(ctx, id)
+}
+
+func (dao *TaskDAO) enableTask(ctx context.Context, t *Task) error {
+	// runs missed while the task was disabled are not caught up
+	nextRunAt, err := dao.nextRunAt(t)
+	if err != nil {
+		return err
+	}
+
+	db := dao.RW()
+	query := `
+		UPDATE tasks
+		SET
+			status = $1,
+			consecutive_failures = 0,
+			disabled_reason = NULL,
+			disabled_at = NULL,
+			paused_by = NULL,
+			paused_at = NULL,
+			pause_reason = NULL,
+			resume_at = NULL,
+			next_run_at = $2,
+			updated_at = $3
+		WHERE id = $4 AND tenant_id = $5 AND status IN ($1, $6)
+	`
+	if _, err := db.ExecContext(ctx, query,
+		StatusActive, nextRunAt, dao.TimeNow(), t.ID, t.TenantID, StatusDisabled,
 	); err != nil {
-		return fmt.Errorf("update task: %w", err)
+		return fmt.Errorf("enable task: %w", err)
 	}
 
 	return nil
 }
+
+// nextRunAt returns the first run of the task after the current time
+func (dao *TaskDAO) nextRunAt(t *Task) (*int64, error) {
+	next, err := t.NextRun(time.Unix(dao.TimeNow(), 0))
+	if err != nil {
+		return nil, fmt.Errorf("next run: %w", err)
+	}
+
+	return typePtr(next.Unix()), nil
+}
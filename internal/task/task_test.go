@@ -0,0 +1,118 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stuckinforloop/ticker/database"
+	"github.com/stuckinforloop/ticker/internal/dao"
+)
+
+// defaultTenantID is the tenant created by the migrations
+const defaultTenantID = "00000000000000000000000000"
+
+// newTestTaskDAO returns a TaskDAO of the default tenant on db with the
+// clock set to now
+func newTestTaskDAO(t *testing.T, db *sql.DB, now *int64) *TaskDAO {
+	d, err := dao.NewTestDAO(
+		dao.WithDB(db, db),
+		dao.WithTimeNow(func() int64 { return *now }),
+	)
+	assert.NoError(t, err)
+
+	return NewTaskDAO(d, defaultTenantID)
+}
+
+// createTestTask stores an http task of the group running every minute
+func createTestTask(t *testing.T, dao *TaskDAO, groupID *string) *Task {
+	tk, err := dao.CreateTask(context.Background(), &Task{
+		GroupID:    groupID,
+		Expression: "* * * * *",
+		URL:        "http://localhost",
+		HTTPMethod: "GET",
+	})
+	assert.NoError(t, err)
+
+	return tk
+}
+
+func TestUpdateTask(t *testing.T) {
+	db := database.NewTestDB(t)
+	now := int64(1700000000)
+	taskDAO := newTestTaskDAO(t, db, &now)
+
+	t.Run("concurrent-pause", func(t *testing.T) {
+		ctx := context.Background()
+		tk := createTestTask(t, taskDAO, nil)
+
+		// the task is paused after it was read for the update
+		stale := *tk
+		_, err := taskDAO.PauseTask(ctx, tk.ID, Pause{})
+		assert.NoError(t, err)
+
+		stale.Expression = "0 * * * *"
+		updated, err := taskDAO.UpdateTask(ctx, &stale)
+		assert.NoError(t, err)
+		assert.Equal(t, "0 * * * *", updated.Expression)
+		assert.Equal(t, StatusDisabled, updated.Status)
+		assert.Equal(t, PausedReason, *updated.DisabledReason)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		ctx := context.Background()
+		tk := createTestTask(t, taskDAO, nil)
+		assert.NoError(t, taskDAO.DeleteTask(ctx, tk.ID))
+
+		updated, err := taskDAO.UpdateTask(ctx, tk)
+		assert.NoError(t, err)
+		assert.Nil(t, updated)
+	})
+}
+
+func TestTenantIsolation(t *testing.T) {
+	db := database.NewTestDB(t)
+	ctx := context.Background()
+	now := int64(1700000000)
+
+	// the tenant package depends on this one, the tenant is inserted as is
+	otherTenantID := "01J00000000000000000000000"
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO tenants (id, name, status, created_at, updated_at)
+		VALUES ($1, 'other', 'active', $2, $2)
+	`, otherTenantID, now)
+	assert.NoError(t, err)
+
+	taskDAO := newTestTaskDAO(t, db, &now)
+	otherDAO := NewTaskDAO(taskDAO.DAO, otherTenantID)
+
+	g, err := otherDAO.CreateGroup(ctx, &Group{Name: "other"})
+	assert.NoError(t, err)
+	other := createTestTask(t, otherDAO, &g.ID)
+	own := createTestTask(t, taskDAO, nil)
+
+	t.Run("list-tasks", func(t *testing.T) {
+		tasks, _, total, err := taskDAO.ListTasks(ctx, ListFilter{Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Len(t, tasks, 1)
+		assert.Equal(t, own.ID, tasks[0].ID)
+	})
+
+	t.Run("get-task", func(t *testing.T) {
+		tk, err := taskDAO.GetTask(ctx, other.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, tk)
+	})
+
+	t.Run("get-group", func(t *testing.T) {
+		got, err := taskDAO.GetGroup(ctx, g.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+
+		got, err = otherDAO.GetGroup(ctx, g.ID)
+		assert.NoError(t, err)
+		assert.NotNil(t, got)
+	})
+}
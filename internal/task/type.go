@@ -0,0 +1,192 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+type Type string
+
+const (
+	TypeHTTP  Type = "http"  // send a request to url
+	TypeShell Type = "shell" // run a command on the executor
+	TypeSQL   Type = "sql"   // run a statement against a configured database
+)
+
+// ShellConfig is the config of shell tasks. Only the tenants listed in
+// executor.shell.tenants may create and run shell tasks.
+type ShellConfig struct {
+	// Command is run with /bin/sh -c
+	Command string `json:"command"`
+
+	// Dir is the working directory of the command, the one of
+	// the executor by default
+	Dir string `json:"dir,omitempty"`
+
+	// Env is the environment of the command along with the PATH of
+	// the executor, nothing else of the executor is passed on
+	Env map[string]string `json:"env,omitempty"`
+
+	// ExitCodes are the exit codes of a successful run, 0 by default
+	ExitCodes []int `json:"exit_codes,omitempty"`
+}
+
+// SQLConfig is the config of sql tasks
+type SQLConfig struct {
+	// DSN is the name of a connection string of executor.sql.dsns the
+	// tenant of the task may use, tasks do not carry credentials
+	DSN string `json:"dsn"`
+
+	Statement string `json:"statement"`
+}
+
+// ShellAllowed reports whether the tenant may create and run shell tasks
+func ShellAllowed(tenantID string) bool {
+	return slices.Contains(viper.GetStringSlice("executor.shell.tenants"), tenantID)
+}
+
+// SQLDSN returns the connection string configured under name if the
+// tenant is one of the tenants allowed to use it
+func SQLDSN(name, tenantID string) (string, bool) {
+	key := "executor.sql.dsns." + name
+	if !slices.Contains(viper.GetStringSlice(key+".tenants"), tenantID) {
+		return "", false
+	}
+
+	dsn := viper.GetString(key + ".dsn")
+	return dsn, dsn != ""
+}
+
+// SuccessExitCodes returns the exit codes of a successful run
+func (c *ShellConfig) SuccessExitCodes() []int {
+	if len(c.ExitCodes) == 0 {
+		return []int{0}
+	}
+
+	return c.ExitCodes
+}
+
+// Validate checks the config of a shell task of the tenant
+func (c *ShellConfig) Validate(tenantID string) error {
+	if !ShellAllowed(tenantID) {
+		return errors.New("shell tasks are not allowed for this tenant")
+	}
+
+	if strings.TrimSpace(c.Command) == "" {
+		return errors.New("command is required")
+	}
+
+	if c.Dir != "" && !filepath.IsAbs(c.Dir) {
+		return errors.New("dir must be an absolute path")
+	}
+
+	for name := range c.Env {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return fmt.Errorf("invalid env name: %q", name)
+		}
+	}
+
+	for _, code := range c.ExitCodes {
+		if code < 0 || code > 255 {
+			return fmt.Errorf("exit code %d must be between 0 and 255", code)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the config of a sql task of the tenant
+func (c *SQLConfig) Validate(tenantID string) error {
+	if c.DSN == "" {
+		return errors.New("dsn is required")
+	}
+
+	if _, ok := SQLDSN(c.DSN, tenantID); !ok {
+		return fmt.Errorf("dsn %s is not configured for this tenant", c.DSN)
+	}
+
+	if strings.TrimSpace(c.Statement) == "" {
+		return errors.New("statement is required")
+	}
+
+	return nil
+}
+
+// ValidateConfig checks that t has the config of its type, and only
+// that one, and that the tenant may use it
+func (t *Task) ValidateConfig(tenantID string) error {
+	switch t.Type {
+	case "", TypeHTTP:
+		if t.Shell != nil || t.SQL != nil {
+			return errors.New("shell and sql configs do not apply to http tasks")
+		}
+
+		return nil
+	case TypeShell:
+		if t.Shell == nil {
+			return errors.New("shell config is required")
+		}
+
+		if t.SQL != nil {
+			return errors.New("sql config does not apply to shell tasks")
+		}
+
+		if err := t.Shell.Validate(tenantID); err != nil {
+			return fmt.Errorf("invalid shell config: %w", err)
+		}
+
+		return nil
+	case TypeSQL:
+		if t.SQL == nil {
+			return errors.New("sql config is required")
+		}
+
+		if t.Shell != nil {
+			return errors.New("shell config does not apply to sql tasks")
+		}
+
+		if err := t.SQL.Validate(tenantID); err != nil {
+			return fmt.Errorf("invalid sql config: %w", err)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("invalid type: %s", t.Type)
+}
+
+// config returns the config of the type of t as stored in the config
+// column, http tasks keep theirs in dedicated columns
+func (t *Task) config() ([]byte, error) {
+	var config any
+	switch t.Type {
+	case TypeShell:
+		config = t.Shell
+	case TypeSQL:
+		config = t.SQL
+	}
+
+	return json.Marshal(config)
+}
+
+// setConfig reads the config column into the config of the type of t
+func (t *Task) setConfig(config []byte) error {
+	if config == nil {
+		return nil
+	}
+
+	switch t.Type {
+	case TypeShell:
+		return json.Unmarshal(config, &t.Shell)
+	case TypeSQL:
+		return json.Unmarshal(config, &t.SQL)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConfig(t *testing.T) {
+	tenantID := "01J00000000000000000000001"
+	otherTenantID := "01J00000000000000000000002"
+
+	viper.Set("executor.shell.tenants", []string{tenantID})
+	viper.Set("executor.sql.dsns.maintenance.dsn", "postgres://localhost/app")
+	viper.Set("executor.sql.dsns.maintenance.tenants", []string{tenantID})
+	defer viper.Set("executor.shell.tenants", nil)
+	defer viper.Set("executor.sql.dsns.maintenance", nil)
+
+	t.Run("http", func(t *testing.T) {
+		assert.NoError(t, (&Task{}).ValidateConfig(tenantID))
+		assert.Error(t, (&Task{Shell: &ShellConfig{Command: "true"}}).ValidateConfig(tenantID))
+	})
+
+	t.Run("shell", func(t *testing.T) {
+		tsk := &Task{Type: TypeShell, Shell: &ShellConfig{
+			Command:   "./backup.sh",
+			Dir:       "/srv/backups",
+			Env:       map[string]string{"TARGET": "s3"},
+			ExitCodes: []int{0, 3},
+		}}
+		assert.NoError(t, tsk.ValidateConfig(tenantID))
+
+		assert.Error(t, (&Task{Type: TypeShell}).ValidateConfig(tenantID))
+		assert.Error(t, (&Task{Type: TypeShell, Shell: &ShellConfig{}}).ValidateConfig(tenantID))
+		assert.Error(t, (&Task{Type: TypeShell, Shell: &ShellConfig{Command: "ls", Dir: "srv"}}).ValidateConfig(tenantID))
+		assert.Error(t, (&Task{Type: TypeShell, Shell: &ShellConfig{Command: "ls", ExitCodes: []int{256}}}).ValidateConfig(tenantID))
+		assert.Error(t, (&Task{Type: TypeShell, Shell: &ShellConfig{Command: "ls", Env: map[string]string{"A=B": ""}}}).ValidateConfig(tenantID))
+	})
+
+	t.Run("shell-other-tenant", func(t *testing.T) {
+		assert.Error(t, (&Task{Type: TypeShell, Shell: &ShellConfig{Command: "ls"}}).ValidateConfig(otherTenantID))
+	})
+
+	t.Run("sql", func(t *testing.T) {
+		tsk := &Task{Type: TypeSQL, SQL: &SQLConfig{DSN: "maintenance", Statement: "VACUUM"}}
+		assert.NoError(t, tsk.ValidateConfig(tenantID))
+
+		assert.Error(t, (&Task{Type: TypeSQL}).ValidateConfig(tenantID))
+		assert.Error(t, (&Task{Type: TypeSQL, SQL: &SQLConfig{DSN: "unknown", Statement: "VACUUM"}}).ValidateConfig(tenantID))
+		assert.Error(t, (&Task{Type: TypeSQL, SQL: &SQLConfig{DSN: "maintenance"}}).ValidateConfig(tenantID))
+
+		// the dsn is not shared with other tenants
+		assert.Error(t, tsk.ValidateConfig(otherTenantID))
+	})
+
+	t.Run("invalid-type", func(t *testing.T) {
+		assert.Error(t, (&Task{Type: "ftp"}).ValidateConfig(tenantID))
+	})
+}
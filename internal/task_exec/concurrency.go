@@ -0,0 +1,239 @@
+package taskexec
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/stuckinforloop/ticker/internal/task"
+	"go.uber.org/zap"
+)
+
+// slotAction is what an executor does with an attempt once the
+// concurrency policy of its task has been applied
+type slotAction int
+
+const (
+	slotRun  slotAction = iota // execute the attempt
+	slotWait                   // enqueue the attempt again until an instance is free
+	slotSkip                   // the run was recorded as skipped
+	slotDrop                   // the execution is over or already running elsewhere
+)
+
+// cancelPollInterval is how often an execution that may be
+// replaced checks whether it was cancelled
+const cancelPollInterval = 2 * time.Second
+
+// slotWaitDelay is how many seconds an attempt waiting for a free
+// instance is held back before it claims a slot again
+const slotWaitDelay int64 = 5
+
+// concurrencyLimit returns how many executions of the task may run at
+// once, 0 for no limit. Forbid and replace allow a single execution
+// unless instances is set.
+func (p *ExecutorPayload) concurrencyLimit() int {
+	limit := 0
+	if p.Instances != nil {
+		limit = *p.Instances
+	}
+
+	switch task.ConcurrencyPolicy(p.ConcurrencyPolicy) {
+	case task.ConcurrencyForbid, task.ConcurrencyReplace:
+		limit = max(limit, 1)
+	}
+
+	return limit
+}
+
+// slotDecision applies the concurrency policy to an attempt given the
+// number of other running executions of the task. For replace it also
+// returns how many of the oldest running executions to cancel.
+func (p *ExecutorPayload) slotDecision(running int) (slotAction, int) {
+	limit := p.concurrencyLimit()
+	if limit == 0 || running < limit {
+		return slotRun, 0
+	}
+
+	switch task.ConcurrencyPolicy(p.ConcurrencyPolicy) {
+	case task.ConcurrencyForbid:
+		return slotSkip, 0
+	case task.ConcurrencyReplace:
+		return slotRun, running - limit + 1
+	}
+
+	return slotWait, 0
+}
+
+// claimSlot marks the execution as running if the concurrency policy of
+// its task allows it. Claims of the executions of a task are serialized
+// by an advisory lock, so the limit holds across executor processes.
+func (dao *TaskExecDAO) claimSlot(ctx context.Context, p ExecutorPayload, attempt int, startedAt int64) (slotAction, error) {
+	action := slotDrop
+	err := dao.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx,
+			`SELECT pg_advisory_xact_lock(hashtext($1))`, p.TaskID,
+		); err != nil {
+			return fmt.Errorf("lock task %s: %w", p.TaskID, err)
+		}
+
+		var status Status
+		var current int
+		query := `
+			SELECT status, attempt
+			FROM task_execs
+			WHERE id = $1
+			FOR UPDATE
+		`
+		if err := tx.QueryRowContext(ctx, query, p.TaskExecID).Scan(&status, &current); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+
+			return fmt.Errorf("select task_exec: %w", err)
+		}
+
+		// the message was delivered again while the attempt is running
+		if status.final() || (status == StatusRunning && current >= attempt) {
+			return nil
+		}
+
+		running, err := listRunning(ctx, tx, p.TaskID, p.TaskExecID)
+		if err != nil {
+			return err
+		}
+
+		var cancel int
+		action, cancel = p.slotDecision(len(running))
+		switch action {
+		case slotSkip:
+			reason := fmt.Sprintf("%d executions already running", len(running))
+			return setSlotStatus(ctx, tx, p.TaskExecID, StatusSkipped, reason, startedAt)
+		case slotRun:
+			reason := fmt.Sprintf("replaced by execution %s", p.TaskExecID)
+			for _, id := range running[:cancel] {
+				if err := setSlotStatus(ctx, tx, id, StatusCancelled, reason, startedAt); err != nil {
+					return err
+				}
+			}
+
+			query := `
+				UPDATE task_execs
+				SET
+					status = $1,
+					attempt = $2,
+					started_at = COALESCE(started_at, $3),
+					updated_at = $3
+				WHERE id = $4
+			`
+			if _, err := tx.ExecContext(ctx, query,
+				StatusRunning, attempt, startedAt, p.TaskExecID,
+			); err != nil {
+				return fmt.Errorf("update task_exec: %w", err)
+			}
+		}
+
+		return nil
+	})
+
+	return action, err
+}
+
+// deferAttempt enqueues the attempt again, held in the outbox for
+// slotWaitDelay seconds. Every wait is deduplicated on its own, so a
+// run may wait for as long as its instances are busy.
+func (dao *TaskExecDAO) deferAttempt(ctx context.Context, p ExecutorPayload, attempt int) error {
+	p.Attempt = attempt
+
+	dedupeID := fmt.Sprintf("%s-%d-%d-%d", p.TaskID, p.RunAt, attempt, dao.TimeNow())
+	return dao.WithTx(ctx, func(tx *sql.Tx) error {
+		return dao.addExecutorMessage(ctx, tx, p, dedupeID, p.TaskExecID, slotWaitDelay)
+	})
+}
+
+// listRunning returns the running executions of a task other than
+// the given one, oldest first
+func listRunning(ctx context.Context, tx *sql.Tx, taskID, execID string) ([]string, error) {
+	query := `
+		SELECT id
+		FROM task_execs
+		WHERE task_id = $1 AND status = $2 AND id <> $3
+		ORDER BY started_at, id
+	`
+	rows, err := tx.QueryContext(ctx, query, taskID, StatusRunning, execID)
+	if err != nil {
+		return nil, fmt.Errorf("list running task_execs: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration: %w", err)
+	}
+
+	return ids, nil
+}
+
+// setSlotStatus finishes an execution as skipped or cancelled
+func setSlotStatus(ctx context.Context, tx *sql.Tx, id string, status Status, reason string, now int64) error {
+	query := `
+		UPDATE task_execs
+		SET
+			status = $1,
+			reason = $2,
+			finished_at = $3,
+			updated_at = $3
+		WHERE id = $4
+	`
+	if _, err := tx.ExecContext(ctx, query, status, reason, now, id); err != nil {
+		return fmt.Errorf("set task_exec %s %s: %w", id, status, err)
+	}
+
+	return nil
+}
+
+// watchCancellation cancels a running attempt once its execution has
+// been cancelled by a newer execution of the task
+func (dao *TaskExecDAO) watchCancellation(ctx context.Context, id string, cancel context.CancelFunc, cancelled *atomic.Bool) {
+	ticker := time.NewTicker(cancelPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			var status Status
+			query := `
+				SELECT status
+				FROM task_execs
+				WHERE id = $1
+			`
+			if err := dao.RO().QueryRowContext(ctx, query, id).Scan(&status); err != nil {
+				if ctx.Err() == nil {
+					dao.Logger.Error("get task_exec status",
+						zap.Error(err),
+						zap.String("task_exec_id", id))
+				}
+				continue
+			}
+
+			if status == StatusCancelled {
+				cancelled.Store(true)
+				cancel()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package taskexec
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stuckinforloop/ticker/database"
+	"github.com/stuckinforloop/ticker/internal/dao"
+	"github.com/stuckinforloop/ticker/internal/queue/broker"
+	"github.com/stuckinforloop/ticker/internal/task"
+	"github.com/stuckinforloop/ticker/internal/tenant"
+)
+
+func TestSlotDecision(t *testing.T) {
+	payload := func(policy task.ConcurrencyPolicy, instances int) ExecutorPayload {
+		return ExecutorPayload{ConcurrencyPolicy: string(policy), Instances: &instances}
+	}
+
+	t.Run("unlimited", func(t *testing.T) {
+		p := payload(task.ConcurrencyAllow, 0)
+		action, _ := p.slotDecision(100)
+		assert.Equal(t, slotRun, action)
+	})
+
+	t.Run("allow", func(t *testing.T) {
+		p := payload(task.ConcurrencyAllow, 2)
+		action, _ := p.slotDecision(1)
+		assert.Equal(t, slotRun, action)
+
+		action, _ = p.slotDecision(2)
+		assert.Equal(t, slotWait, action)
+	})
+
+	t.Run("forbid", func(t *testing.T) {
+		// forbid defaults to a single instance
+		p := payload(task.ConcurrencyForbid, 0)
+		action, _ := p.slotDecision(0)
+		assert.Equal(t, slotRun, action)
+
+		action, _ = p.slotDecision(1)
+		assert.Equal(t, slotSkip, action)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		p := payload(task.ConcurrencyReplace, 2)
+		action, cancel := p.slotDecision(1)
+		assert.Equal(t, slotRun, action)
+		assert.Equal(t, 0, cancel)
+
+		action, cancel = p.slotDecision(3)
+		assert.Equal(t, slotRun, action)
+		assert.Equal(t, 2, cancel)
+	})
+}
+
+func TestExecuteTaskBusy(t *testing.T) {
+	db := database.NewTestDB(t)
+
+	t.Run("deferred", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		var now atomic.Int64
+		now.Store(time.Now().Unix())
+		d, err := dao.NewTestDAO(
+			dao.WithDB(db, db),
+			dao.WithTimeNow(now.Load),
+		)
+		assert.NoError(t, err)
+
+		instances := 1
+		tk, err := task.NewTaskDAO(d, tenant.DefaultID).CreateTask(ctx, &task.Task{
+			Expression:        "* * * * *",
+			URL:               "http://localhost",
+			HTTPMethod:        "GET",
+			ConcurrencyPolicy: task.ConcurrencyAllow,
+			Instances:         &instances,
+		})
+		assert.NoError(t, err)
+
+		taskExecDAO := NewTaskExecDAO(d)
+		taskExecDAO.Queue = broker.NewMemory()
+		go taskExecDAO.RelayOutbox(ctx)
+
+		assert.NoError(t, taskExecDAO.fireTask(ctx, *tk, now.Load()-60))
+		assert.NoError(t, taskExecDAO.fireTask(ctx, *tk, now.Load()))
+
+		// the first run takes up the only instance
+		_, message, err := taskExecDAO.Queue.Dequeue(ctx, ExecutorQueueName, 300)
+		assert.NoError(t, err)
+
+		p := ExecutorPayload{}
+		assert.NoError(t, json.Unmarshal([]byte(message), &p))
+
+		action, err := taskExecDAO.claimSlot(ctx, p, 1, now.Load())
+		assert.NoError(t, err)
+		assert.Equal(t, slotRun, action)
+
+		// the second run is acknowledged and held back in the outbox
+		messageID, message, err := taskExecDAO.Queue.Dequeue(ctx, ExecutorQueueName, 300)
+		assert.NoError(t, err)
+		assert.NoError(t, taskExecDAO.executeTask(ctx, messageID, message))
+
+		waitCtx, waitCancel := context.WithTimeout(ctx, 3*time.Second)
+		defer waitCancel()
+
+		messageID, _, err = taskExecDAO.Queue.Dequeue(waitCtx, ExecutorQueueName, 300)
+		assert.NoError(t, err)
+		assert.Empty(t, messageID)
+
+		now.Add(slotWaitDelay)
+		_, message, err = taskExecDAO.Queue.Dequeue(ctx, ExecutorQueueName, 300)
+		assert.NoError(t, err)
+
+		deferred := ExecutorPayload{}
+		assert.NoError(t, json.Unmarshal([]byte(message), &deferred))
+		assert.Equal(t, p.TaskID, deferred.TaskID)
+		assert.NotEqual(t, p.TaskExecID, deferred.TaskExecID)
+	})
+}
@@ -1,19 +1,38 @@
 package taskexec
 
 import (
+	"fmt"
+
 	"github.com/stuckinforloop/ticker/internal/dao"
+	"github.com/stuckinforloop/ticker/internal/notification"
 	"github.com/stuckinforloop/ticker/internal/queue"
 )
 
 type TaskExecDAO struct {
 	*dao.DAO
-	Queue queue.Queue
+	Queue    queue.Queue
+	Notifier notification.Notifier
 }
 
+// NewTaskExecDAO returns a TaskExecDAO that sends no notifications,
+// handlers reporting outcomes call SetupNotifier at startup
 func NewTaskExecDAO(dao *dao.DAO) *TaskExecDAO {
-	queue := queue.New()
+	queue := queue.New(dao.RW())
 	return &TaskExecDAO{
 		dao,
 		queue,
+		notification.Notifiers{},
 	}
 }
+
+// SetupNotifier sends notifications to the channels of the config. It
+// returns an error if they are not configured properly.
+func (dao *TaskExecDAO) SetupNotifier() error {
+	notifier, err := notification.New()
+	if err != nil {
+		return fmt.Errorf("setup notifications: %w", err)
+	}
+
+	dao.Notifier = notifier
+	return nil
+}
@@ -3,13 +3,16 @@ package taskexec
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/stuckinforloop/ticker/internal/task"
 	"go.uber.org/zap"
 )
 
-// TODO: add retry logic for tasks with non-zero retry value
 func (dao *TaskExecDAO) ExecuteTasks(ctx context.Context) error {
 	maxConcurrent := 20
 	semaphore := make(chan bool, maxConcurrent)
@@ -56,7 +59,37 @@ func (dao *TaskExecDAO) executeTask(ctx context.Context, messageID, message stri
 		return fmt.Errorf("unmarshal sqs message failed: %w", err)
 	}
 
+	attempt := max(execPayload.Attempt, 1)
+
+	// wait until the run is due before taking up an instance
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Unix(execPayload.RunAt, 0).Sub(time.Unix(dao.TimeNow(), 0))):
+	}
+
 	now := dao.TimeNow()
+	action, err := dao.claimSlot(ctx, execPayload, attempt, now)
+	if err != nil {
+		return fmt.Errorf("claim slot (%s): %w", execPayload.TaskExecID, err)
+	}
+
+	switch action {
+	case slotWait:
+		if err := dao.deferAttempt(ctx, execPayload, attempt); err != nil {
+			return fmt.Errorf("defer attempt (%s): %w", execPayload.TaskExecID, err)
+		}
+
+		dao.Logger.Info("Execute deferred, all instances busy",
+			zap.String("task_exec_id", execPayload.TaskExecID))
+		return dao.acknowledge(ctx, messageID, message)
+	case slotSkip, slotDrop:
+		dao.Logger.Info("Execute skipped",
+			zap.String("task_exec_id", execPayload.TaskExecID),
+			zap.Bool("duplicate", action == slotDrop))
+		return dao.acknowledge(ctx, messageID, message)
+	}
+
 	te := TaskExec{
 		ID:         execPayload.TaskExecID,
 		TaskID:     execPayload.TaskID,
@@ -65,37 +98,65 @@ func (dao *TaskExecDAO) executeTask(ctx context.Context, messageID, message stri
 		StartedAt:  typePtr(now),
 		FinishedAt: nil,
 		Response:   nil,
+		Attempt:    attempt,
 	}
-	sqsMsg, err := json.Marshal(te)
-	if err != nil {
-		return fmt.Errorf("marshal sqs message failed: %w", err)
+
+	execCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var cancelled atomic.Bool
+	if task.ConcurrencyPolicy(execPayload.ConcurrencyPolicy) == task.ConcurrencyReplace {
+		go dao.watchCancellation(execCtx, te.ID, cancel, &cancelled)
 	}
 
-	if _, err := dao.Queue.Enqueue(ctx, NotifierQueueName, string(sqsMsg), nil, nil, int64(0)); err != nil {
-		return fmt.Errorf("sqs enqueue before execution failed (%s): %w", te.ID, err)
+	resp, err := execPayload.execute(execCtx)
+	if cancelled.Load() {
+		dao.Logger.Info("Execute cancelled",
+			zap.String("task_exec_id", te.ID),
+			zap.Int("attempt", attempt))
+		return dao.acknowledge(ctx, messageID, message)
 	}
 
-	resp, err := execPayload.execute(ctx, now)
 	if err != nil {
 		dao.Logger.Error("execute failed", zap.Error(err))
 		te.Status = StatusFailed
+		te.Error = typePtr(err.Error())
+		errors.As(err, &te.FailedAssertion)
 	} else {
 		te.Status = StatusCompleted
 	}
 
 	dao.Logger.Info("Execute complete ",
 		zap.String("task_exec_id", te.ID),
+		zap.Int("attempt", attempt),
 		zap.String("status", string(te.Status)))
 
-	if resp != nil {
-		if err := json.Unmarshal(resp, &te.Response); err != nil {
-			return fmt.Errorf("unmarshal response: %w", err)
+	te.Response = resp
+
+	record := Attempt{
+		Attempt:   attempt,
+		Status:    te.Status,
+		StartedAt: now,
+		Error:     te.Error,
+	}
+
+	if te.Status == StatusFailed && execPayload.shouldRetry(attempt) {
+		retryAt, err := dao.retryTask(ctx, execPayload, attempt)
+		if err != nil {
+			dao.Logger.Error("retry failed",
+				zap.Error(err),
+				zap.String("task_exec_id", te.ID))
+		} else {
+			te.Status = StatusRetrying
+			record.RetryAt = typePtr(retryAt)
 		}
 	}
 
 	te.FinishedAt = typePtr(dao.TimeNow())
+	record.FinishedAt = *te.FinishedAt
+	te.Attempts = []Attempt{record}
 
-	sqsMsg, err = json.Marshal(te)
+	sqsMsg, err := json.Marshal(te)
 	if err != nil {
 		return fmt.Errorf("marshal sqs message failed: %w", err)
 	}
@@ -105,6 +166,10 @@ func (dao *TaskExecDAO) executeTask(ctx context.Context, messageID, message stri
 		return fmt.Errorf("sqs enqueue after execution failed (%s): %w", te.ID, err)
 	}
 
+	return dao.acknowledge(ctx, messageID, message)
+}
+
+func (dao *TaskExecDAO) acknowledge(ctx context.Context, messageID, message string) error {
 	if err := dao.Queue.Acknowledge(ctx, messageID, ExecutorQueueName); err != nil {
 		return fmt.Errorf("sqs acknowledge failed: %w", err)
 	}
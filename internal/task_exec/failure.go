@@ -0,0 +1,104 @@
+package taskexec
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/stuckinforloop/ticker/internal/task"
+	"go.uber.org/zap"
+)
+
+// taskOutcome is the state of a task after the final status
+// of one of its executions has been recorded.
+type taskOutcome struct {
+	TaskID              string
+	Name                string
+	PreviousFailures    int
+	ConsecutiveFailures int
+	FailureThreshold    int
+	Notify              bool
+	NotifyEvery         int
+	Disabled            bool
+}
+
+// recordOutcome updates the consecutive failure count of the task that
+// te belongs to and disables the task once failure_threshold is crossed.
+// It returns nil if te is not in a final status or the task was deleted.
+func (dao *TaskExecDAO) recordOutcome(ctx context.Context, te *TaskExec) (*taskOutcome, error) {
+	if !te.Status.final() {
+		return nil, nil
+	}
+
+	tx, err := dao.RW().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	o := taskOutcome{TaskID: te.TaskID}
+	var status task.Status
+	query := `
+		SELECT
+			name, consecutive_failures, failure_threshold,
+			notify, notify_every, status
+		FROM tasks
+		WHERE id = $1
+		FOR UPDATE
+	`
+	if err := tx.QueryRowContext(ctx, query, te.TaskID).Scan(
+		&o.Name, &o.PreviousFailures, &o.FailureThreshold,
+		&o.Notify, &o.NotifyEvery, &status,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("select task: %w", err)
+	}
+
+	// executions that timed out or were lost count as failures
+	if te.Status != StatusCompleted {
+		o.ConsecutiveFailures = o.PreviousFailures + 1
+	}
+
+	var disabledReason *string
+	var disabledAt *int64
+	if status == task.StatusActive && o.FailureThreshold > 0 &&
+		o.ConsecutiveFailures >= o.FailureThreshold {
+		o.Disabled = true
+		status = task.StatusDisabled
+		disabledReason = typePtr(fmt.Sprintf(
+			"disabled after %d consecutive failures", o.ConsecutiveFailures))
+		disabledAt = typePtr(dao.TimeNow())
+	}
+
+	query = `
+		UPDATE tasks
+		SET
+			consecutive_failures = $1,
+			status = $2,
+			disabled_reason = COALESCE($3, disabled_reason),
+			disabled_at = COALESCE($4, disabled_at),
+			updated_at = COALESCE($4, updated_at)
+		WHERE id = $5
+	`
+	if _, err := tx.ExecContext(ctx, query,
+		o.ConsecutiveFailures, status, disabledReason, disabledAt, te.TaskID,
+	); err != nil {
+		return nil, fmt.Errorf("update task: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+
+	if o.Disabled {
+		dao.Logger.Warn("Task disabled",
+			zap.String("task_id", te.TaskID),
+			zap.Int("consecutive_failures", o.ConsecutiveFailures))
+	}
+
+	return &o, nil
+}
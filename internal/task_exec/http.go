@@ -0,0 +1,78 @@
+package taskexec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// httpExecutor sends the request of the task and records its response.
+// A response not meeting the success criteria of the task is returned
+// along with the *task.AssertionFailure, body assertions only see the
+// recorded part of the body.
+type httpExecutor struct{}
+
+func (httpExecutor) execute(ctx context.Context, p *ExecutorPayload) (*Response, error) {
+	var req *http.Request
+	var err error
+
+	t := newTimer()
+	ctx = httptrace.WithClientTrace(ctx, t.trace())
+
+	switch p.HTTPMethod {
+	case "GET", "HEAD", "DELETE":
+		req, err = http.NewRequestWithContext(ctx, p.HTTPMethod, p.URL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
+
+	case "POST", "PUT", "PATCH":
+		reqPayload, err := json.Marshal(p.PostData)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal post data: %w", err)
+		}
+
+		req, err = http.NewRequestWithContext(ctx, p.HTTPMethod, p.URL, bytes.NewReader(reqPayload))
+		if err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported http method: %s", p.HTTPMethod)
+	}
+
+	// bodies are sent as JSON unless the task sets its own content type
+	if req.Body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	for k, v := range p.HTTPHeaders {
+		req.Header.Set(k, fmt.Sprint(v))
+	}
+
+	client := http.Client{
+		Timeout: time.Duration(time.Second * time.Duration(*p.Timeout)),
+		// TODO: add support for sending cookies
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	r, err := readResponse(resp, t)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if f := p.SuccessCriteria.Check(r.StatusCode, resp.Header, r.Body, r.Timings.Total); f != nil {
+		return r, f
+	}
+
+	return r, nil
+}
@@ -0,0 +1,101 @@
+package taskexec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/spf13/viper"
+	"github.com/stuckinforloop/ticker/internal/lease"
+	"go.uber.org/zap"
+)
+
+// LeaderLease is the lease held by the scheduler instance doing housekeeping
+const LeaderLease = "scheduler-leader"
+
+// defaultLeaseTTL is the number of seconds after which the lease of a
+// crashed leader can be taken over by another instance
+const defaultLeaseTTL int64 = 15
+
+// InstanceID identifies this process when acquiring leases
+var InstanceID = sync.OnceValue(func() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+})
+
+func leaseTTL() int64 {
+	if ttl := viper.GetInt64("scheduler.lease_ttl"); ttl > 0 {
+		return ttl
+	}
+
+	return defaultLeaseTTL
+}
+
+// elector keeps track of the leadership of this scheduler instance
+type elector struct {
+	leaseDAO *lease.LeaseDAO
+	logger   *zap.Logger
+	isLeader bool
+	leader   string
+}
+
+// elect acquires or renews the leader lease and logs leadership changes.
+// It returns true while this instance is the leader.
+func (e *elector) elect(ctx context.Context) bool {
+	l, err := e.leaseDAO.Acquire(ctx, LeaderLease, InstanceID(), leaseTTL())
+	if err != nil {
+		// without a renewed lease another instance may take over
+		e.logger.Error("acquire leader lease", zap.Error(err))
+		if e.isLeader {
+			e.logger.Warn("Lost leadership", zap.String("instance_id", InstanceID()))
+		}
+
+		e.isLeader = false
+		e.leader = ""
+		return false
+	}
+
+	leader := ""
+	if l != nil {
+		leader = l.Holder
+	}
+
+	isLeader := leader == InstanceID()
+	switch {
+	case isLeader && !e.isLeader:
+		e.logger.Info("Became leader", zap.String("instance_id", InstanceID()))
+	case !isLeader && e.isLeader:
+		e.logger.Warn("Lost leadership",
+			zap.String("instance_id", InstanceID()),
+			zap.String("leader", leader))
+	case !isLeader && leader != e.leader:
+		e.logger.Info("Following leader",
+			zap.String("instance_id", InstanceID()),
+			zap.String("leader", leader))
+	}
+
+	e.isLeader = isLeader
+	e.leader = leader
+	return isLeader
+}
+
+// resign releases the leader lease so that another instance
+// can take over without waiting for the lease to expire
+func (e *elector) resign(ctx context.Context) {
+	if !e.isLeader {
+		return
+	}
+
+	if err := e.leaseDAO.Release(ctx, LeaderLease, InstanceID()); err != nil {
+		e.logger.Error("release leader lease", zap.Error(err))
+		return
+	}
+
+	e.isLeader = false
+	e.logger.Info("Resigned leadership", zap.String("instance_id", InstanceID()))
+}
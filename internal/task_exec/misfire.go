@@ -0,0 +1,134 @@
+package taskexec
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stuckinforloop/ticker/internal/task"
+	"go.uber.org/zap"
+)
+
+// maxMissedRuns bounds the number of missed runs recorded at once,
+// older runs of a long outage are dropped without a trace
+const maxMissedRuns = 1000
+
+// missedRuns returns the runs of t from its next_run_at up to before,
+// along with the first run at or after before
+func missedRuns(t task.Task, before int64) ([]int64, int64, error) {
+	runs := []int64{}
+	runAt := *t.NextRunAt
+	for runAt < before {
+		if len(runs) == maxMissedRuns {
+			next, err := t.NextRun(time.Unix(before-1, 0))
+			if err != nil {
+				return nil, 0, err
+			}
+
+			return runs, next.Unix(), nil
+		}
+
+		runs = append(runs, runAt)
+
+		next, err := t.NextRun(time.Unix(runAt, 0))
+		if err != nil {
+			return nil, 0, err
+		}
+		runAt = next.Unix()
+	}
+
+	return runs, runAt, nil
+}
+
+// splitMissedRuns applies the misfire policy of t to its missed runs.
+// It returns the runs to fire late and the runs to record as missed,
+// fire_all fires the latest misfire_limit runs.
+func splitMissedRuns(t task.Task, runs []int64) ([]int64, []int64) {
+	limit := 0
+	switch t.MisfirePolicy {
+	case task.MisfireFireOnce:
+		limit = 1
+	case task.MisfireFireAll:
+		limit = task.MaxMisfireLimit
+		if t.MisfireLimit != nil {
+			limit = min(*t.MisfireLimit, limit)
+		}
+	}
+
+	split := max(len(runs)-limit, 0)
+	return runs[split:], runs[:split]
+}
+
+// catchUp handles the runs of t missed while no scheduler was running,
+// e.g. before startup or until the shard of t was taken over. Missed runs
+// are fired or recorded according to the misfire policy of t and the
+// first run at or after before is returned.
+func (dao *TaskExecDAO) catchUp(ctx context.Context, t task.Task, before int64) (int64, error) {
+	runs, runAt, err := missedRuns(t, before)
+	if err != nil {
+		return 0, fmt.Errorf("missed runs of task %s: %w", t.ID, err)
+	}
+
+	fire, missed := splitMissedRuns(t, runs)
+	if err := dao.recordMissedRuns(ctx, t, missed); err != nil {
+		return 0, err
+	}
+
+	for _, missedRunAt := range fire {
+		if err := dao.fireTask(ctx, t, missedRunAt); err != nil {
+			return 0, err
+		}
+	}
+
+	dao.Logger.Warn("Caught up missed runs",
+		zap.String("task_id", t.ID),
+		zap.String("misfire_policy", string(t.MisfirePolicy)),
+		zap.Int("fired", len(fire)),
+		zap.Int("missed", len(missed)))
+
+	return runAt, nil
+}
+
+// recordMissedRuns creates executions in the missed status so that
+// the gap shows up in the history of the task
+func (dao *TaskExecDAO) recordMissedRuns(ctx context.Context, t task.Task, runs []int64) error {
+	if len(runs) == 0 {
+		return nil
+	}
+
+	return dao.WithTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO task_execs (
+				id, tenant_id, task_id, status, run_at,
+				finished_at, error, created_at, updated_at
+			) Values (
+				$1, $2, $3, $4, $5,
+				$6, $7, $8, $9
+			)
+			ON CONFLICT DO NOTHING
+		`
+		for _, runAt := range runs {
+			te := &TaskExec{
+				TenantID:   t.TenantID,
+				TaskID:     t.ID,
+				Status:     StatusMissed,
+				RunAt:      runAt,
+				FinishedAt: typePtr(dao.TimeNow()),
+				Error:      typePtr("missed while no scheduler was running"),
+				CreatedAt:  dao.TimeNow(),
+				UpdatedAt:  dao.TimeNow(),
+			}
+			dao.fillDefaults(te)
+
+			if _, err := tx.ExecContext(ctx, query,
+				te.ID, te.TenantID, te.TaskID, te.Status, te.RunAt,
+				te.FinishedAt, te.Error, te.CreatedAt, te.UpdatedAt,
+			); err != nil {
+				return fmt.Errorf("record missed run of task %s: %w", t.ID, err)
+			}
+		}
+
+		return nil
+	})
+}
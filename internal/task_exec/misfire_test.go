@@ -0,0 +1,68 @@
+package taskexec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stuckinforloop/ticker/internal/task"
+)
+
+func TestMissedRuns(t *testing.T) {
+	nextRunAt := int64(1700000040) // 2023-11-14 22:14:00 UTC
+	tsk := task.Task{
+		Expression: "* * * * *",
+		Timezone:   task.UTC,
+		NextRunAt:  &nextRunAt,
+	}
+
+	t.Run("gap", func(t *testing.T) {
+		runs, next, err := missedRuns(tsk, nextRunAt+150)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{nextRunAt, nextRunAt + 60, nextRunAt + 120}, runs)
+		assert.Equal(t, nextRunAt+180, next)
+	})
+
+	t.Run("no-gap", func(t *testing.T) {
+		runs, next, err := missedRuns(tsk, nextRunAt)
+		assert.NoError(t, err)
+		assert.Empty(t, runs)
+		assert.Equal(t, nextRunAt, next)
+	})
+
+	t.Run("capped", func(t *testing.T) {
+		before := nextRunAt + 60*(maxMissedRuns+500) + 30
+		runs, next, err := missedRuns(tsk, before)
+		assert.NoError(t, err)
+		assert.Len(t, runs, maxMissedRuns)
+		assert.Equal(t, before+30, next)
+	})
+}
+
+func TestSplitMissedRuns(t *testing.T) {
+	runs := []int64{60, 120, 180, 240}
+
+	t.Run("skip", func(t *testing.T) {
+		fire, missed := splitMissedRuns(task.Task{MisfirePolicy: task.MisfireSkip}, runs)
+		assert.Empty(t, fire)
+		assert.Equal(t, runs, missed)
+	})
+
+	t.Run("fire-once", func(t *testing.T) {
+		fire, missed := splitMissedRuns(task.Task{MisfirePolicy: task.MisfireFireOnce}, runs)
+		assert.Equal(t, []int64{240}, fire)
+		assert.Equal(t, []int64{60, 120, 180}, missed)
+	})
+
+	t.Run("fire-all", func(t *testing.T) {
+		limit := 3
+		fire, missed := splitMissedRuns(task.Task{MisfirePolicy: task.MisfireFireAll, MisfireLimit: &limit}, runs)
+		assert.Equal(t, []int64{120, 180, 240}, fire)
+		assert.Equal(t, []int64{60}, missed)
+
+		limit = 10
+		fire, missed = splitMissedRuns(task.Task{MisfirePolicy: task.MisfireFireAll, MisfireLimit: &limit}, runs)
+		assert.Equal(t, runs, fire)
+		assert.Empty(t, missed)
+	})
+}
@@ -35,13 +35,28 @@ func (dao *TaskExecDAO) UpdateTaskStatusNotify(ctx context.Context) error {
 				continue
 			}
 
-			if err := dao.updateTaskExec(ctx, &te); err != nil {
+			updated, err := dao.updateTaskExec(ctx, &te)
+			if err != nil {
 				dao.Logger.Error("update task exec: %w",
 					zap.Error(err),
 					zap.String("task_exec_id", te.ID),
 				)
 			}
 
+			if updated {
+				outcome, err := dao.recordOutcome(ctx, &te)
+				if err != nil {
+					dao.Logger.Error("record outcome",
+						zap.Error(err),
+						zap.String("task_id", te.TaskID),
+					)
+				}
+
+				if outcome != nil {
+					dao.notify(ctx, &te, outcome)
+				}
+			}
+
 			err = dao.Queue.Acknowledge(ctx, messageID, NotifierQueueName)
 			if err != nil {
 				dao.Logger.Error("Ack failed", zap.Error(err))
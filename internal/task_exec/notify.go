@@ -0,0 +1,66 @@
+package taskexec
+
+import (
+	"context"
+	"time"
+
+	"github.com/stuckinforloop/ticker/internal/notification"
+	"go.uber.org/zap"
+)
+
+// notification returns the event to send for the outcome of an execution.
+// Failures are reported on every notify_every-th consecutive failure and
+// a recovery only if at least one failure of the streak was reported.
+func (o *taskOutcome) notification(status Status) (notification.EventType, bool) {
+	if !o.Notify {
+		return "", false
+	}
+
+	every := max(o.NotifyEvery, 1)
+	switch {
+	case o.Disabled:
+		return notification.EventDisabled, true
+	case status != StatusCompleted && o.ConsecutiveFailures%every == 0:
+		return notification.EventFailed, true
+	case status == StatusCompleted && o.PreviousFailures >= every:
+		return notification.EventRecovered, true
+	}
+
+	return "", false
+}
+
+// notifyTimeout bounds the delivery of a notification to every channel
+const notifyTimeout = 30 * time.Second
+
+// notify sends the notification of the outcome in the background, slow
+// channels do not hold back the notifier loop or the reaper
+func (dao *TaskExecDAO) notify(ctx context.Context, te *TaskExec, o *taskOutcome) {
+	eventType, ok := o.notification(te.Status)
+	if !ok {
+		return
+	}
+
+	e := notification.Event{
+		Type:                eventType,
+		TaskID:              o.TaskID,
+		TaskName:            o.Name,
+		TaskExecID:          te.ID,
+		RunAt:               te.RunAt,
+		ConsecutiveFailures: o.ConsecutiveFailures,
+		Error:               te.Error,
+		Timestamp:           dao.TimeNow(),
+	}
+
+	// the notification outlives the message being handled
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+	go func() {
+		defer cancel()
+
+		if err := dao.Notifier.Notify(ctx, e); err != nil {
+			dao.Logger.Error("send notification",
+				zap.Error(err),
+				zap.String("task_id", e.TaskID),
+				zap.String("event", string(e.Type)))
+		}
+	}()
+}
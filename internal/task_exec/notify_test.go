@@ -0,0 +1,59 @@
+package taskexec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stuckinforloop/ticker/internal/notification"
+)
+
+func TestNotification(t *testing.T) {
+	t.Run("notify-disabled", func(t *testing.T) {
+		o := taskOutcome{Notify: false, ConsecutiveFailures: 1}
+		_, ok := o.notification(StatusFailed)
+		assert.False(t, ok)
+	})
+
+	t.Run("every-failure", func(t *testing.T) {
+		o := taskOutcome{Notify: true, NotifyEvery: 0, ConsecutiveFailures: 1}
+		eventType, ok := o.notification(StatusFailed)
+		assert.True(t, ok)
+		assert.Equal(t, notification.EventFailed, eventType)
+	})
+
+	t.Run("every-nth-failure", func(t *testing.T) {
+		for failures, expected := range map[int]bool{1: false, 2: false, 3: true, 4: false, 6: true} {
+			o := taskOutcome{Notify: true, NotifyEvery: 3, ConsecutiveFailures: failures}
+			_, ok := o.notification(StatusFailed)
+			assert.Equal(t, expected, ok, "failures: %d", failures)
+		}
+	})
+
+	t.Run("reaped", func(t *testing.T) {
+		for _, status := range []Status{StatusTimedOut, StatusLost} {
+			o := taskOutcome{Notify: true, NotifyEvery: 1, ConsecutiveFailures: 1}
+			eventType, ok := o.notification(status)
+			assert.True(t, ok, "status: %s", status)
+			assert.Equal(t, notification.EventFailed, eventType)
+		}
+	})
+
+	t.Run("recovered", func(t *testing.T) {
+		o := taskOutcome{Notify: true, NotifyEvery: 3, PreviousFailures: 2}
+		_, ok := o.notification(StatusCompleted)
+		assert.False(t, ok)
+
+		o.PreviousFailures = 3
+		eventType, ok := o.notification(StatusCompleted)
+		assert.True(t, ok)
+		assert.Equal(t, notification.EventRecovered, eventType)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		o := taskOutcome{Notify: true, NotifyEvery: 3, ConsecutiveFailures: 20, Disabled: true}
+		eventType, ok := o.notification(StatusFailed)
+		assert.True(t, ok)
+		assert.Equal(t, notification.EventDisabled, eventType)
+	})
+}
@@ -0,0 +1,92 @@
+package taskexec
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/stuckinforloop/ticker/internal/quota"
+	"github.com/stuckinforloop/ticker/internal/task"
+	"go.uber.org/zap"
+)
+
+// CountExecutions returns the number of executions of the tenant created
+// at or after since that count against the max_executions_per_hour quota.
+// Missed and skipped runs are not counted.
+func (dao *TaskExecDAO) CountExecutions(ctx context.Context, tenantID string, since int64) (int64, error) {
+	db := dao.RO()
+	query := `
+		SELECT COUNT(*)
+		FROM task_execs
+		WHERE tenant_id = $1 AND created_at >= $2 AND status <> ALL($3)
+	`
+	var count int64
+	if err := db.QueryRowContext(ctx, query,
+		tenantID, since, pq.Array([]Status{StatusMissed, StatusSkipped}),
+	).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count task_execs: %w", err)
+	}
+
+	return count, nil
+}
+
+// checkExecutionQuota returns a quota.ExceededError if the tenant may not
+// fire another execution. Schedulers of different shards may fire at the
+// same time, so the quota can be exceeded by a few executions.
+func (dao *TaskExecDAO) checkExecutionQuota(ctx context.Context, tenantID string) error {
+	q, err := quota.NewQuotaDAO(dao.DAO).GetQuotas(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	if q.MaxExecutionsPerHour == 0 {
+		return nil
+	}
+
+	count, err := dao.CountExecutions(ctx, tenantID, dao.TimeNow()-3600)
+	if err != nil {
+		return err
+	}
+
+	return q.CheckExecutions(count + 1)
+}
+
+// skipRun records a run of t that was not fired as skipped
+func (dao *TaskExecDAO) skipRun(ctx context.Context, t task.Task, runAt int64, reason string) error {
+	te := &TaskExec{
+		TenantID:   t.TenantID,
+		TaskID:     t.ID,
+		Status:     StatusSkipped,
+		RunAt:      runAt,
+		FinishedAt: typePtr(dao.TimeNow()),
+		Reason:     &reason,
+		CreatedAt:  dao.TimeNow(),
+		UpdatedAt:  dao.TimeNow(),
+	}
+	dao.fillDefaults(te)
+
+	db := dao.RW()
+	query := `
+		INSERT INTO task_execs (
+			id, tenant_id, task_id, status, run_at,
+			finished_at, reason, created_at, updated_at
+		) Values (
+			$1, $2, $3, $4, $5,
+			$6, $7, $8, $9
+		)
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := db.ExecContext(ctx, query,
+		te.ID, te.TenantID, te.TaskID, te.Status, te.RunAt,
+		te.FinishedAt, te.Reason, te.CreatedAt, te.UpdatedAt,
+	); err != nil {
+		return fmt.Errorf("record skipped run of task %s: %w", t.ID, err)
+	}
+
+	dao.Logger.Warn("Skipped run",
+		zap.String("task_id", t.ID),
+		zap.Int64("run_at", runAt),
+		zap.String("reason", reason))
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package taskexec
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/viper"
+	"github.com/stuckinforloop/ticker/internal/task"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultReaperGrace is the number of seconds an execution may
+	// be overdue before it is reaped
+	defaultReaperGrace int64 = 300
+
+	// defaultReaperInterval is the number of seconds between two reaps
+	defaultReaperInterval int64 = 30
+
+	// reapBatchSize is the number of executions reaped at once
+	reapBatchSize = 100
+)
+
+func reaperGrace() int64 {
+	if grace := viper.GetInt64("reaper.grace"); grace > 0 {
+		return grace
+	}
+
+	return defaultReaperGrace
+}
+
+func reaperInterval() int64 {
+	if interval := viper.GetInt64("reaper.interval"); interval > 0 {
+		return interval
+	}
+
+	return defaultReaperInterval
+}
+
+// reaper finds executions whose executor never reported back. Executions
+// still pending are lost, executions still running have timed out. Both
+// are retried if reaper.requeue is set and attempts are left.
+type reaper struct {
+	dao     *TaskExecDAO
+	lastRun int64
+}
+
+// reap runs once every reaper.interval seconds
+func (r *reaper) reap(ctx context.Context) {
+	now := r.dao.TimeNow()
+	if now-r.lastRun < reaperInterval() {
+		return
+	}
+	r.lastRun = now
+
+	execs, err := r.dao.listStuckTaskExecs(ctx, now-reaperGrace())
+	if err != nil {
+		r.dao.Logger.Error("list stuck task_execs", zap.Error(err))
+		return
+	}
+
+	for _, e := range execs {
+		if err := r.dao.reapTaskExec(ctx, &e); err != nil {
+			r.dao.Logger.Error("reap task_exec",
+				zap.Error(err),
+				zap.String("task_exec_id", e.ID))
+		}
+	}
+}
+
+// listStuckTaskExecs returns the executions that should have reported
+// back before the given time. Pending executions are due at run_at, or
+// at creation for missed runs fired late, running ones after the task
+// timeout and retrying ones after the longest retry delay.
+func (dao *TaskExecDAO) listStuckTaskExecs(ctx context.Context, before int64) ([]TaskExec, error) {
+	db := dao.RO()
+	query := `
+		SELECT
+			e.id, e.tenant_id, e.task_id, e.status, e.run_at,
+			e.attempt, e.updated_at
+		FROM task_execs e
+		LEFT JOIN tasks t ON t.id = e.task_id
+		WHERE (e.status = $1 AND GREATEST(e.run_at, e.created_at) < $4)
+			OR (e.status = $2 AND e.updated_at + COALESCE(t.timeout, 0) < $4)
+			OR (e.status = $3 AND e.updated_at + $5 < $4)
+		ORDER BY e.run_at
+		LIMIT $6
+	`
+	rows, err := db.QueryContext(ctx, query,
+		StatusPending, StatusRunning, StatusRetrying,
+		before, maxRetryDelay, reapBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("list stuck task_execs: %w", err)
+	}
+	defer rows.Close()
+
+	execs := []TaskExec{}
+	for rows.Next() {
+		e := TaskExec{}
+		if err := rows.Scan(
+			&e.ID, &e.TenantID, &e.TaskID, &e.Status, &e.RunAt,
+			&e.Attempt, &e.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		execs = append(execs, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration: %w", err)
+	}
+
+	return execs, nil
+}
+
+// reapTaskExec marks a stuck execution as timed out or lost, or
+// enqueues its next attempt if it is retried
+func (dao *TaskExecDAO) reapTaskExec(ctx context.Context, e *TaskExec) error {
+	now := dao.TimeNow()
+	attempt := max(e.Attempt, 1)
+
+	record := Attempt{
+		Attempt:    attempt,
+		Status:     StatusLost,
+		StartedAt:  e.UpdatedAt,
+		FinishedAt: now,
+		Error:      typePtr("executor message was not picked up"),
+	}
+	if e.Status == StatusRunning {
+		record.Status = StatusTimedOut
+		record.Error = typePtr("executor did not report back")
+	}
+
+	te := TaskExec{
+		ID:         e.ID,
+		TenantID:   e.TenantID,
+		TaskID:     e.TaskID,
+		Status:     record.Status,
+		RunAt:      e.RunAt,
+		FinishedAt: typePtr(now),
+		Attempt:    attempt,
+		Error:      record.Error,
+	}
+
+	var t *task.Task
+	if viper.GetBool("reaper.requeue") {
+		var err error
+		t, err = task.NewTaskDAO(dao.DAO, e.TenantID).GetTask(ctx, e.TaskID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// a lost retry has not been attempted, it keeps its attempt number
+	nextAttempt := attempt + 1
+	if e.Status == StatusRetrying {
+		nextAttempt = attempt
+	}
+
+	requeue := t != nil && t.Status == task.StatusActive &&
+		t.MaxAttempts != nil && nextAttempt <= *t.MaxAttempts
+	if requeue {
+		te.Status = StatusRetrying
+		te.FinishedAt = nil
+		te.Attempt = nextAttempt
+		record.RetryAt = typePtr(now)
+	}
+
+	attempts, err := json.Marshal([]Attempt{record})
+	if err != nil {
+		return fmt.Errorf("marshal attempts: %w", err)
+	}
+
+	reaped := false
+	if err := dao.WithTx(ctx, func(tx *sql.Tx) error {
+		// the executor may have reported back since the execution was listed
+		query := `
+			UPDATE task_execs
+			SET
+				status = $1,
+				finished_at = $2,
+				attempt = $3,
+				attempts = COALESCE(attempts, '[]'::jsonb) || $4::jsonb,
+				error = $5,
+				updated_at = $6
+			WHERE id = $7 AND status = $8 AND updated_at = $9
+		`
+		res, err := tx.ExecContext(ctx, query,
+			te.Status, te.FinishedAt, te.Attempt, attempts, te.Error,
+			now, e.ID, e.Status, e.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("update task_exec: %w", err)
+		}
+
+		updated, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("update task_exec: %w", err)
+		}
+
+		reaped = updated > 0
+		if !reaped || !requeue {
+			return nil
+		}
+
+		dedupeID := fmt.Sprintf("%s-%d-%d", e.TaskID, e.RunAt, te.Attempt)
+		payload := newExecutorPayload(*t, e.ID, e.RunAt, te.Attempt)
+		return dao.addExecutorMessage(ctx, tx, payload, dedupeID, e.ID, 0)
+	}); err != nil {
+		return err
+	}
+
+	if !reaped {
+		return nil
+	}
+
+	dao.Logger.Warn("Reaped task_exec",
+		zap.String("task_exec_id", e.ID),
+		zap.String("previous_status", string(e.Status)),
+		zap.String("status", string(te.Status)),
+		zap.Int("attempt", te.Attempt))
+
+	outcome, err := dao.recordOutcome(ctx, &te)
+	if err != nil {
+		return fmt.Errorf("record outcome: %w", err)
+	}
+
+	if outcome != nil {
+		dao.notify(ctx, &te, outcome)
+	}
+
+	return nil
+}
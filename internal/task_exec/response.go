@@ -0,0 +1,208 @@
+package taskexec
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// defaultMaxBodySize is the number of bytes of a response body kept
+// on the execution, the rest is counted but dropped
+const defaultMaxBodySize = 64 << 10
+
+// maxDrainSize is the number of bytes read past executor.max_body_size
+// so that the connection can be reused, larger bodies are not read to
+// the end
+const maxDrainSize = 256 << 10
+
+// defaultResponseHeaders are the response headers kept on the execution
+var defaultResponseHeaders = []string{
+	"Content-Type", "Content-Length", "Content-Encoding", "Location",
+	"Retry-After", "Server", "X-Request-Id",
+}
+
+func maxBodySize() int64 {
+	if size := viper.GetInt64("executor.max_body_size"); size > 0 {
+		return size
+	}
+
+	return defaultMaxBodySize
+}
+
+func responseHeaders() []string {
+	if headers := viper.GetStringSlice("executor.response_headers"); len(headers) > 0 {
+		return headers
+	}
+
+	return defaultResponseHeaders
+}
+
+// Response records the outcome of an execution: the HTTP response of
+// http tasks, the exit code and output of shell tasks or the number of
+// rows affected by sql tasks. The body is kept as text whatever its
+// content type, up to executor.max_body_size bytes. Invalid UTF-8 and
+// NUL bytes, which postgres does not store in jsonb, are replaced by
+// U+FFFD. BodySize is the Content-Length of bodies too large to be read
+// to the end, the number of bytes read if the length is unknown.
+type Response struct {
+	StatusCode   int               `json:"status_code,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	ExitCode     *int              `json:"exit_code,omitempty"`
+	RowsAffected *int64            `json:"rows_affected,omitempty"`
+	BodySize     int64             `json:"body_size"`
+	Body         string            `json:"body"`
+	Truncated    bool              `json:"truncated"`
+	Timings      Timings           `json:"timings"`
+}
+
+// Timings break down the latency of a request in milliseconds. Phases
+// skipped by the request, such as DNS for a reused connection, are 0.
+type Timings struct {
+	DNS     float64 `json:"dns_ms"`
+	Connect float64 `json:"connect_ms"`
+	TLS     float64 `json:"tls_ms"`
+	TTFB    float64 `json:"ttfb_ms"` // from sending the request to the first response byte
+	Total   float64 `json:"total_ms"`
+}
+
+// timer collects the timings of a request from its httptrace hooks,
+// which may be called from several goroutines
+type timer struct {
+	mu sync.Mutex
+
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+
+	dns     time.Duration
+	connect time.Duration
+	tls     time.Duration
+	ttfb    time.Duration
+}
+
+func newTimer() *timer {
+	return &timer{start: time.Now()}
+}
+
+// trace returns the hooks recording the timings of a request. Phases of
+// redirected requests add up.
+func (t *timer) trace() *httptrace.ClientTrace {
+	since := func(start *time.Time, d *time.Duration) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		if !start.IsZero() {
+			*d += time.Since(*start)
+			*start = time.Time{}
+		}
+	}
+
+	mark := func(start *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		// parallel dials of a host start only once
+		if start.IsZero() {
+			*start = time.Now()
+		}
+	}
+
+	return &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { mark(&t.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { since(&t.dnsStart, &t.dns) },
+		ConnectStart:      func(string, string) { mark(&t.connectStart) },
+		ConnectDone:       func(string, string, error) { since(&t.connectStart, &t.connect) },
+		TLSHandshakeStart: func() { mark(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { since(&t.tlsStart, &t.tls) },
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.ttfb = time.Since(t.start)
+		},
+	}
+}
+
+func (t *timer) timings() Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ms := func(d time.Duration) float64 {
+		return float64(d.Microseconds()) / 1000
+	}
+
+	return Timings{
+		DNS:     ms(t.dns),
+		Connect: ms(t.connect),
+		TLS:     ms(t.tls),
+		TTFB:    ms(t.ttfb),
+		Total:   ms(time.Since(t.start)),
+	}
+}
+
+// body keeps the first executor.max_body_size bytes written to it,
+// the rest is only counted
+type body struct {
+	buf   bytes.Buffer
+	limit int64
+	size  int64
+}
+
+func newBody() *body {
+	return &body{limit: maxBodySize()}
+}
+
+func (b *body) Write(p []byte) (int, error) {
+	if room := b.limit - int64(b.buf.Len()); room > 0 {
+		b.buf.Write(p[:min(int64(len(p)), room)])
+	}
+	b.size += int64(len(p))
+
+	return len(p), nil
+}
+
+// record sets the body of r
+func (b *body) record(r *Response) {
+	r.BodySize = b.size
+	// a truncated body may end in the middle of a character
+	r.Body = strings.ToValidUTF8(b.buf.String(), "\uFFFD")
+	r.Body = strings.ReplaceAll(r.Body, "\x00", "\uFFFD")
+	r.Truncated = b.size > int64(b.buf.Len())
+}
+
+// readResponse reads the body of resp and records it along with the
+// selected headers of resp
+func readResponse(resp *http.Response, t *timer) (*Response, error) {
+	b := newBody()
+	readLimit := b.limit + maxDrainSize
+	if _, err := io.Copy(b, io.LimitReader(resp.Body, readLimit)); err != nil {
+		return nil, err
+	}
+
+	r := &Response{
+		StatusCode: resp.StatusCode,
+		Headers:    map[string]string{},
+		Timings:    t.timings(),
+	}
+	b.record(r)
+
+	// the rest of the body is left unread
+	if b.size == readLimit {
+		r.BodySize = max(r.BodySize, resp.ContentLength)
+	}
+
+	for _, name := range responseHeaders() {
+		if v := resp.Header.Values(name); len(v) > 0 {
+			r.Headers[http.CanonicalHeaderKey(name)] = strings.Join(v, ", ")
+		}
+	}
+
+	return r, nil
+}
@@ -0,0 +1,163 @@
+package taskexec
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stuckinforloop/ticker/internal/task"
+)
+
+func TestExecuteResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/text":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("X-Secret", "hidden")
+			w.Write([]byte("ok"))
+		case "/html":
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("<h1>down</h1>"))
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		case "/large":
+			w.Write([]byte(strings.Repeat("é", 10)))
+		case "/nul":
+			w.Write([]byte("a\x00b"))
+		case "/headers":
+			w.Write([]byte(r.Header.Get("X-Token") + " " + r.Header.Get("Content-Type")))
+		case "/huge":
+			w.Header().Set("Content-Length", strconv.Itoa(4*maxDrainSize))
+			w.Write(bytes.Repeat([]byte("a"), 4*maxDrainSize))
+		case "/huge-chunked":
+			// flushing before writing the body drops the Content-Length
+			w.(http.Flusher).Flush()
+			w.Write(bytes.Repeat([]byte("a"), 4*maxDrainSize))
+		}
+	}))
+	defer srv.Close()
+
+	execute := func(path string) *Response {
+		p := ExecutorPayload{URL: srv.URL + path, HTTPMethod: "GET", Timeout: typePtr(5)}
+		resp, err := p.execute(context.Background())
+		assert.NoError(t, err)
+		return resp
+	}
+
+	t.Run("text", func(t *testing.T) {
+		resp := execute("/text")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "ok", resp.Body)
+		assert.Equal(t, int64(2), resp.BodySize)
+		assert.False(t, resp.Truncated)
+		assert.Equal(t, "text/plain", resp.Headers["Content-Type"])
+		assert.NotContains(t, resp.Headers, "X-Secret")
+		assert.Greater(t, resp.Timings.Total, 0.0)
+	})
+
+	t.Run("html-error", func(t *testing.T) {
+		// tasks without criteria succeed whatever the status code
+		resp := execute("/html")
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, "<h1>down</h1>", resp.Body)
+
+		// the response is recorded along with the failed criteria
+		p := ExecutorPayload{
+			URL:             srv.URL + "/html",
+			HTTPMethod:      "GET",
+			Timeout:         typePtr(5),
+			SuccessCriteria: &task.SuccessCriteria{},
+		}
+		resp, err := p.execute(context.Background())
+		assert.Equal(t, "<h1>down</h1>", resp.Body)
+
+		var failure *task.AssertionFailure
+		assert.ErrorAs(t, err, &failure)
+		assert.Equal(t, "status_codes", failure.Assertion)
+	})
+
+	t.Run("success-criteria", func(t *testing.T) {
+		p := ExecutorPayload{
+			URL:        srv.URL + "/html",
+			HTTPMethod: "GET",
+			Timeout:    typePtr(5),
+			SuccessCriteria: &task.SuccessCriteria{
+				StatusCodes: []string{"503"},
+				Body:        []task.Assertion{{Contains: "down"}},
+			},
+		}
+		_, err := p.execute(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("no-content", func(t *testing.T) {
+		resp := execute("/empty")
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, "", resp.Body)
+		assert.Equal(t, int64(0), resp.BodySize)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		viper.Set("executor.max_body_size", 5)
+		defer viper.Set("executor.max_body_size", nil)
+
+		// the limit falls in the middle of the third character
+		resp := execute("/large")
+		assert.Equal(t, "éé\uFFFD", resp.Body)
+		assert.Equal(t, int64(20), resp.BodySize)
+		assert.True(t, resp.Truncated)
+	})
+
+	t.Run("nul", func(t *testing.T) {
+		resp := execute("/nul")
+		assert.Equal(t, "a\uFFFDb", resp.Body)
+		assert.Equal(t, int64(3), resp.BodySize)
+	})
+
+	t.Run("undrained", func(t *testing.T) {
+		viper.Set("executor.max_body_size", 5)
+		defer viper.Set("executor.max_body_size", nil)
+
+		// the body is not read to the end, its size is the Content-Length
+		resp := execute("/huge")
+		assert.Equal(t, "aaaaa", resp.Body)
+		assert.Equal(t, int64(4*maxDrainSize), resp.BodySize)
+		assert.True(t, resp.Truncated)
+
+		// or the number of bytes read if the length is unknown
+		resp = execute("/huge-chunked")
+		assert.Equal(t, int64(5+maxDrainSize), resp.BodySize)
+		assert.True(t, resp.Truncated)
+	})
+
+	t.Run("headers", func(t *testing.T) {
+		p := ExecutorPayload{
+			URL:         srv.URL + "/headers",
+			HTTPMethod:  "POST",
+			HTTPHeaders: map[string]any{"X-Token": 42},
+			Timeout:     typePtr(5),
+		}
+		resp, err := p.execute(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "42 application/json", resp.Body)
+
+		// the content type of the task replaces the default one
+		p.HTTPHeaders["Content-Type"] = "text/plain"
+		resp, err = p.execute(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "42 text/plain", resp.Body)
+	})
+
+	t.Run("unsupported-method", func(t *testing.T) {
+		p := ExecutorPayload{URL: srv.URL, HTTPMethod: "TRACE", Timeout: typePtr(5)}
+		_, err := p.execute(context.Background())
+		assert.Error(t, err)
+	})
+}
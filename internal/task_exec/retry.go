@@ -0,0 +1,61 @@
+package taskexec
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+
+	"github.com/stuckinforloop/ticker/internal/task"
+)
+
+// maxRetryDelay is the longest delay supported by SQS (15 minutes)
+const maxRetryDelay int64 = 900
+
+// shouldRetry reports whether a failed attempt is followed by another one.
+// Retries are enabled by a non-zero retry_after and bounded by max_attempts.
+func (p *ExecutorPayload) shouldRetry(attempt int) bool {
+	if p.RetryAfter == nil || *p.RetryAfter <= 0 {
+		return false
+	}
+
+	return p.MaxAttempts != nil && attempt < *p.MaxAttempts
+}
+
+// retryDelay returns the number of seconds to wait before retrying
+// a failed attempt. Exponential backoff doubles retry_after for every
+// attempt and applies equal jitter, i.e. a random delay in [d/2, d].
+func (p *ExecutorPayload) retryDelay(attempt int, jitter func(n int64) int64) int64 {
+	delay := int64(*p.RetryAfter)
+	if task.Backoff(p.RetryBackoff) == task.BackoffExponential {
+		for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+			delay *= 2
+		}
+
+		delay = min(delay, maxRetryDelay)
+		delay = delay/2 + jitter(delay/2+1)
+	}
+
+	return min(delay, maxRetryDelay)
+}
+
+// retryTask enqueues the next attempt of the execution and returns
+// the time at which it becomes available to executors. The attempt is
+// held in the outbox until then.
+func (dao *TaskExecDAO) retryTask(ctx context.Context, p ExecutorPayload, attempt int) (int64, error) {
+	delay := p.retryDelay(attempt, rand.Int63n)
+
+	p.Attempt = attempt + 1
+
+	// retries are grouped by execution so that a delayed retry
+	// does not hold back the upcoming runs of the task
+	dedupeID := fmt.Sprintf("%s-%d-%d", p.TaskID, p.RunAt, p.Attempt)
+	groupID := p.TaskExecID
+	if err := dao.WithTx(ctx, func(tx *sql.Tx) error {
+		return dao.addExecutorMessage(ctx, tx, p, dedupeID, groupID, delay)
+	}); err != nil {
+		return 0, fmt.Errorf("enqueue retry failed: %w", err)
+	}
+
+	return dao.TimeNow() + delay, nil
+}
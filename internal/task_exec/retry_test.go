@@ -0,0 +1,40 @@
+package taskexec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stuckinforloop/ticker/internal/task"
+)
+
+func TestRetry(t *testing.T) {
+	noJitter := func(n int64) int64 { return n - 1 }
+
+	t.Run("should-retry", func(t *testing.T) {
+		p := ExecutorPayload{RetryAfter: typePtr(0), MaxAttempts: typePtr(3)}
+		assert.False(t, p.shouldRetry(1))
+
+		p.RetryAfter = typePtr(10)
+		assert.True(t, p.shouldRetry(1))
+		assert.True(t, p.shouldRetry(2))
+		assert.False(t, p.shouldRetry(3))
+	})
+
+	t.Run("fixed-delay", func(t *testing.T) {
+		p := ExecutorPayload{RetryAfter: typePtr(10), RetryBackoff: string(task.BackoffFixed)}
+		assert.Equal(t, int64(10), p.retryDelay(1, noJitter))
+		assert.Equal(t, int64(10), p.retryDelay(5, noJitter))
+	})
+
+	t.Run("exponential-delay", func(t *testing.T) {
+		p := ExecutorPayload{RetryAfter: typePtr(10), RetryBackoff: string(task.BackoffExponential)}
+		assert.Equal(t, int64(10), p.retryDelay(1, noJitter))
+		assert.Equal(t, int64(20), p.retryDelay(2, noJitter))
+		assert.Equal(t, int64(40), p.retryDelay(3, noJitter))
+		assert.Equal(t, maxRetryDelay, p.retryDelay(50, noJitter))
+
+		noDelay := func(n int64) int64 { return 0 }
+		assert.Equal(t, int64(20), p.retryDelay(3, noDelay))
+	})
+}
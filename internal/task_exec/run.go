@@ -0,0 +1,76 @@
+package taskexec
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/stuckinforloop/ticker/internal/task"
+	"go.uber.org/zap"
+)
+
+// RunOverrides replaces parts of the request sent by a manual run
+type RunOverrides struct {
+	HTTPHeaders map[string]any `json:"http_headers"`
+	PostData    map[string]any `json:"post_data"`
+}
+
+// Validate checks that the overrides apply to the type of t, only the
+// request of http tasks can be replaced
+func (o RunOverrides) Validate(t task.Task) error {
+	if t.Type == "" || t.Type == task.TypeHTTP {
+		return nil
+	}
+
+	if o.HTTPHeaders != nil || o.PostData != nil {
+		return fmt.Errorf("http_headers and post_data do not apply to %s tasks", t.Type)
+	}
+
+	return nil
+}
+
+// RunTask creates a manual execution of t due now, outside of its cron
+// schedule, and enqueues it through the outbox like scheduled runs. It
+// returns a quota.ExceededError if the tenant is over its execution quota.
+func (dao *TaskExecDAO) RunTask(ctx context.Context, t task.Task, o RunOverrides) (*TaskExec, error) {
+	if err := dao.checkExecutionQuota(ctx, t.TenantID); err != nil {
+		return nil, err
+	}
+
+	exec := &TaskExec{
+		TenantID: t.TenantID,
+		TaskID:   t.ID,
+		Status:   StatusPending,
+		RunAt:    dao.TimeNow(),
+		Manual:   true,
+	}
+
+	if err := dao.WithTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		exec, err = dao.createTaskExec(ctx, tx, exec)
+		if err != nil {
+			return err
+		}
+
+		payload := newExecutorPayload(t, exec.ID, exec.RunAt, 1)
+		if o.HTTPHeaders != nil {
+			payload.HTTPHeaders = o.HTTPHeaders
+		}
+
+		if o.PostData != nil {
+			payload.PostData = o.PostData
+		}
+
+		// manual runs are grouped by execution so that they are not
+		// held back by the scheduled runs of the task
+		return dao.addExecutorMessage(ctx, tx, payload, exec.ID, exec.ID, 0)
+	}); err != nil {
+		return nil, err
+	}
+
+	dao.Logger.Info("Manual run created",
+		zap.String("task_id", t.ID),
+		zap.String("task_exec_id", exec.ID))
+
+	return exec, nil
+}
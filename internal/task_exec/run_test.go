@@ -0,0 +1,26 @@
+package taskexec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stuckinforloop/ticker/internal/task"
+)
+
+func TestRunOverrides(t *testing.T) {
+	headers := RunOverrides{HTTPHeaders: map[string]any{"X-Token": "abc"}}
+	body := RunOverrides{PostData: map[string]any{"id": 1}}
+
+	t.Run("http", func(t *testing.T) {
+		assert.NoError(t, headers.Validate(task.Task{Type: task.TypeHTTP}))
+		assert.NoError(t, body.Validate(task.Task{}))
+	})
+
+	t.Run("shell-and-sql", func(t *testing.T) {
+		for _, typ := range []task.Type{task.TypeShell, task.TypeSQL} {
+			assert.Error(t, headers.Validate(task.Task{Type: typ}))
+			assert.Error(t, body.Validate(task.Task{Type: typ}))
+			assert.NoError(t, RunOverrides{}.Validate(task.Task{Type: typ}))
+		}
+	})
+}
@@ -2,27 +2,74 @@ package taskexec
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
 
-	"github.com/gitploy-io/cronexpr"
+	"github.com/lib/pq"
+	"github.com/stuckinforloop/ticker/internal/lease"
+	"github.com/stuckinforloop/ticker/internal/outbox"
+	"github.com/stuckinforloop/ticker/internal/quota"
 	"github.com/stuckinforloop/ticker/internal/task"
+	"github.com/stuckinforloop/ticker/internal/tenant"
 	"go.uber.org/zap"
 )
 
+// ScheduleTasks enqueues due tasks every second. Tasks are split into
+// shards (scheduler.shards) and every scheduler instance only enqueues
+// the tasks of the shards it holds a lease for. One of the instances is
+// also elected leader to take care of cluster wide housekeeping, such
+// as expiring tenants, resuming paused tasks and reaping executions
+// whose executor never reported back.
 func (dao *TaskExecDAO) ScheduleTasks(ctx context.Context) error {
-	ticker := time.NewTicker(3 * time.Second)
+	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
+	// a tick is skipped while the tasks of the previous one are enqueued
+	busy := make(chan struct{}, 1)
+
+	leaseDAO := lease.NewLeaseDAO(dao.DAO)
+	e := &elector{
+		leaseDAO: leaseDAO,
+		logger:   dao.Logger,
+	}
+	defer e.resign(context.Background())
+
+	s := &sharder{
+		leaseDAO: leaseDAO,
+		logger:   dao.Logger,
+		count:    shardCount(),
+	}
+	defer s.release(context.Background())
+
+	r := &reaper{dao: dao}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
 		case <-ticker.C:
+			if e.elect(ctx) {
+				dao.runLeaderTasks(ctx, leaseDAO, r)
+			}
+
+			shards := s.claim(ctx)
+			if len(shards) == 0 {
+				continue
+			}
+
+			select {
+			case busy <- struct{}{}:
+			default:
+				continue
+			}
+
 			go func() {
-				tasks, err := dao.listTasks(ctx)
+				defer func() { <-busy }()
+
+				tasks, err := dao.listTasks(ctx, s.count, shards)
 				if err != nil {
 					dao.Logger.Error("list tasks", zap.Error(err))
 				}
@@ -33,20 +80,52 @@ func (dao *TaskExecDAO) ScheduleTasks(ctx context.Context) error {
 	}
 }
 
-func (dao *TaskExecDAO) listTasks(ctx context.Context) ([]task.Task, error) {
+// runLeaderTasks runs the housekeeping done by the leader on every tick
+func (dao *TaskExecDAO) runLeaderTasks(ctx context.Context, leaseDAO *lease.LeaseDAO, r *reaper) {
+	// instances that stopped heartbeating long ago are already
+	// ignored when assigning shards, keep the table small
+	if err := leaseDAO.PruneInstances(ctx, 10*leaseTTL()); err != nil {
+		dao.Logger.Error("prune scheduler instances", zap.Error(err))
+	}
+
+	expired, err := tenant.NewTenantDAO(dao.DAO).ExpireDueTenants(ctx)
+	if err != nil {
+		dao.Logger.Error("expire tenants", zap.Error(err))
+	}
+
+	if expired > 0 {
+		dao.Logger.Info("Expired tenants", zap.Int64("count", expired))
+	}
+
+	// resumes the tasks of every tenant
+	resumed, err := task.NewTaskDAO(dao.DAO, "").ResumeDueTasks(ctx)
+	if err != nil {
+		dao.Logger.Error("resume paused tasks", zap.Error(err))
+	}
+
+	if resumed > 0 {
+		dao.Logger.Info("Resumed paused tasks", zap.Int64("count", resumed))
+	}
+
+	r.reap(ctx)
+}
+
+// listTasks returns the active tasks of the given shards due within
+// the lookahead window, or whose next run has not been computed yet
+func (dao *TaskExecDAO) listTasks(ctx context.Context, count int, shards []int64) ([]task.Task, error) {
 	dao.Logger.Info("Attempting enqueue")
 
 	db := dao.RO()
 	query := `
-		SELECT
-			id, expression, timezone,
-			timeout, instances, url, http_method, http_headers,
-			post_data, retry_after, failure_threshold, notify,
-			notify_every
+		SELECT ` + task.Columns + `
 		FROM tasks
 		WHERE status = $1
+			AND shard_key % $2 = ANY($3)
+			AND (next_run_at IS NULL OR next_run_at <= $4)
+		ORDER BY next_run_at
 	`
-	rows, err := db.QueryContext(ctx, query, task.StatusActive)
+	rows, err := db.QueryContext(ctx, query,
+		task.StatusActive, count, pq.Array(shards), dao.TimeNow()+lookahead)
 	if err != nil {
 		return nil, fmt.Errorf("get tasks: %w", err)
 	}
@@ -54,26 +133,16 @@ func (dao *TaskExecDAO) listTasks(ctx context.Context) ([]task.Task, error) {
 
 	tasks := []task.Task{}
 	for rows.Next() {
-		t := task.Task{}
-		headers := []byte{}
-		postData := []byte{}
-		if err := rows.Scan(
-			&t.ID, &t.Expression, &t.Timezone, &t.Timeout,
-			&t.Instances, &t.URL, &t.HTTPMethod, &headers, &postData,
-			&t.RetryAfter, &t.FailureThreshold, &t.Notify, &t.NotifyEvery,
-		); err != nil {
+		t, err := task.Scan(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scan row: %w", err)
 		}
 
-		if err := json.Unmarshal(headers, &t.HTTPHeaders); err != nil {
-			return nil, fmt.Errorf("unmarshal http headers: %w", err)
-		}
-
-		if err := json.Unmarshal(postData, &t.PostData); err != nil {
-			return nil, fmt.Errorf("unmarshal post data: %w", err)
-		}
+		tasks = append(tasks, *t)
+	}
 
-		tasks = append(tasks, t)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration: %w", err)
 	}
 
 	return tasks, nil
@@ -100,80 +169,190 @@ func (dao *TaskExecDAO) enqueueTasks(ctx context.Context, tasks []task.Task) {
 	wg.Wait()
 }
 
+const (
+	// lookahead is how many seconds before its run a task is enqueued,
+	// executors wait until run_at before executing it
+	lookahead int64 = 10
+
+	// misfireGrace is how many seconds late a run may still be enqueued
+	misfireGrace int64 = 60
+)
+
+// enqueueTask enqueues the run of a task due within the lookahead window
+// and advances its next_run_at to the following run.
 func (dao *TaskExecDAO) enqueueTask(ctx context.Context, t task.Task) error {
 	currentTimeUnix := dao.TimeNow()
 	currentTime := time.Unix(currentTimeUnix, 0)
 
-	nextTime := cronexpr.MustParse(t.Expression).Next(currentTime)
-	if nextTime.Before(currentTime.Add(10 * time.Second)) {
-		runAt := nextTime.Unix()
-
-		existingExec, err := dao.findTaskExec(ctx, t.ID, runAt)
+	// next_run_at is unknown for tasks created before it was tracked, they
+	// start over from the current time. A next_run_at older than the grace
+	// period means runs were missed, they are handled by the misfire policy.
+	var runAt int64
+	switch {
+	case t.NextRunAt == nil:
+		nextTime, err := t.NextRun(currentTime)
+		if err != nil {
+			return fmt.Errorf("next run of task %s: %w", t.ID, err)
+		}
+		runAt = nextTime.Unix()
+	case *t.NextRunAt < currentTimeUnix-misfireGrace:
+		var err error
+		runAt, err = dao.catchUp(ctx, t, currentTimeUnix-misfireGrace)
 		if err != nil {
-			return fmt.Errorf("error finding existing task_exec: %w", err)
+			return err
 		}
+	default:
+		runAt = *t.NextRunAt
+	}
 
-		if existingExec != nil {
-			dao.Logger.Info(
-				"Task Execution exists already",
-				zap.String("task_id", t.ID),
-				zap.Int64("run_at", runAt),
-				zap.String("task_exec_id", existingExec.ID))
+	if runAt > currentTimeUnix+lookahead {
+		return dao.setNextRunAt(ctx, t, runAt)
+	}
 
-			return nil
-		}
+	if err := dao.fireTask(ctx, t, runAt); err != nil {
+		return err
+	}
 
-		exec := &TaskExec{
-			TaskID: t.ID,
-			Status: StatusPending,
-			RunAt:  runAt,
-		}
+	nextTime, err := t.NextRun(time.Unix(runAt, 0))
+	if err != nil {
+		return fmt.Errorf("next run of task %s: %w", t.ID, err)
+	}
 
-		exec, err = dao.createTaskExec(ctx, exec)
-		if err != nil {
-			return fmt.Errorf("error creating task_exec: %w", err)
-		}
+	return dao.setNextRunAt(ctx, t, nextTime.Unix())
+}
 
+// setNextRunAt stores the next run of a task unless it was
+// changed since the task was listed
+func (dao *TaskExecDAO) setNextRunAt(ctx context.Context, t task.Task, nextRunAt int64) error {
+	if t.NextRunAt != nil && *t.NextRunAt == nextRunAt {
+		return nil
+	}
+
+	db := dao.RW()
+	query := `
+		UPDATE tasks
+		SET next_run_at = $1
+		WHERE id = $2 AND next_run_at IS NOT DISTINCT FROM $3
+	`
+	if _, err := db.ExecContext(ctx, query, nextRunAt, t.ID, t.NextRunAt); err != nil {
+		return fmt.Errorf("set next_run_at of task %s: %w", t.ID, err)
+	}
+
+	return nil
+}
+
+// fireTask creates the execution of a task for runAt along with its
+// executor message in the outbox. Both are written in one transaction,
+// the outbox relay publishes the message to the queue. Runs over the
+// execution quota of the tenant are recorded as skipped.
+func (dao *TaskExecDAO) fireTask(ctx context.Context, t task.Task, runAt int64) error {
+	existingExec, err := dao.findTaskExec(ctx, t.ID, runAt)
+	if err != nil {
+		return fmt.Errorf("error finding existing task_exec: %w", err)
+	}
+
+	if existingExec != nil {
 		dao.Logger.Info(
-			"Create task_exec success",
-			zap.String("task_exec_id", exec.ID))
-
-		payload := ExecutorPayload{
-			TaskID:           t.ID,
-			TaskExecID:       exec.ID,
-			RunAt:            runAt,
-			Timezone:         string(t.Timezone),
-			Timeout:          t.Timeout,
-			Instances:        t.Instances,
-			URL:              t.URL,
-			HTTPMethod:       t.HTTPMethod,
-			HTTPHeaders:      t.HTTPHeaders,
-			PostData:         t.PostData,
-			RetryAfter:       t.RetryAfter,
-			FailureThreshold: t.FailureThreshold,
-			Notify:           t.Notify,
-			NotifyEvery:      t.NotifyEvery,
-		}
+			"Task Execution exists already",
+			zap.String("task_id", t.ID),
+			zap.Int64("run_at", runAt),
+			zap.String("task_exec_id", existingExec.ID))
 
-		message, err := json.Marshal(payload)
-		if err != nil {
-			return fmt.Errorf("marshal sqs message failed: %w", err)
+		return nil
+	}
+
+	if err := dao.checkExecutionQuota(ctx, t.TenantID); err != nil {
+		if quota.IsExceeded(err) {
+			return dao.skipRun(ctx, t, runAt, err.Error())
 		}
 
-		dedupeID := fmt.Sprintf("%s-%d", t.ID, nextTime.Unix())
-		groupID := t.ID
-		messageID, err := dao.Queue.Enqueue(ctx, ExecutorQueueName, string(message), typePtr(dedupeID), typePtr(groupID), int64(0))
+		return err
+	}
+
+	exec := &TaskExec{
+		TenantID: t.TenantID,
+		TaskID:   t.ID,
+		Status:   StatusPending,
+		RunAt:    runAt,
+	}
+
+	if err := dao.WithTx(ctx, func(tx *sql.Tx) error {
+		exec, err = dao.createTaskExec(ctx, tx, exec)
 		if err != nil {
-			return fmt.Errorf("sqs enqueue failed: %w", err)
+			return fmt.Errorf("error creating task_exec: %w", err)
 		}
 
-		dao.Logger.Info(
-			"Enqueue success",
-			zap.Time("current_time", currentTime),
-			zap.String("task", t.ID),
-			zap.String("task_expression", t.Expression),
-			zap.String("message_id", messageID))
+		// runs are grouped by execution, the concurrency policy of the
+		// task decides whether they overlap rather than the queue
+		dedupeID := fmt.Sprintf("%s-%d", t.ID, runAt)
+		payload := newExecutorPayload(t, exec.ID, runAt, 1)
+		return dao.addExecutorMessage(ctx, tx, payload, dedupeID, exec.ID, 0)
+	}); err != nil {
+		return err
 	}
 
+	dao.Logger.Info(
+		"Create task_exec success",
+		zap.Int64("run_at", runAt),
+		zap.String("task", t.ID),
+		zap.String("task_expression", t.Expression),
+		zap.String("task_exec_id", exec.ID))
+
 	return nil
 }
+
+// RelayOutbox publishes the messages written to the outbox by the
+// scheduler to the queue
+func (dao *TaskExecDAO) RelayOutbox(ctx context.Context) error {
+	return outbox.NewOutboxDAO(dao.DAO, dao.Queue).Relay(ctx)
+}
+
+// newExecutorPayload returns the executor message for an attempt
+// of the execution of t at runAt
+func newExecutorPayload(t task.Task, execID string, runAt int64, attempt int) ExecutorPayload {
+	return ExecutorPayload{
+		TenantID:          t.TenantID,
+		TaskID:            t.ID,
+		TaskExecID:        execID,
+		RunAt:             runAt,
+		Timezone:          string(t.Timezone),
+		Timeout:           t.Timeout,
+		Instances:         t.Instances,
+		ConcurrencyPolicy: string(t.ConcurrencyPolicy),
+		Type:              string(t.Type),
+		URL:               t.URL,
+		HTTPMethod:        t.HTTPMethod,
+		HTTPHeaders:       t.HTTPHeaders,
+		PostData:          t.PostData,
+		RetryAfter:        t.RetryAfter,
+		RetryBackoff:      string(t.RetryBackoff),
+		MaxAttempts:       t.MaxAttempts,
+		Attempt:           attempt,
+		FailureThreshold:  t.FailureThreshold,
+		Notify:            t.Notify,
+		NotifyEvery:       t.NotifyEvery,
+		SuccessCriteria:   t.SuccessCriteria,
+		Shell:             t.Shell,
+		SQL:               t.SQL,
+	}
+}
+
+// addExecutorMessage writes the executor message to the outbox as part
+// of tx, it is published once delay seconds have passed
+func (dao *TaskExecDAO) addExecutorMessage(
+	ctx context.Context, tx *sql.Tx, p ExecutorPayload, dedupeID, groupID string, delay int64,
+) error {
+	message, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("marshal sqs message failed: %w", err)
+	}
+
+	outboxDAO := outbox.NewOutboxDAO(dao.DAO, dao.Queue)
+	return outboxDAO.Add(ctx, tx, &outbox.Message{
+		QueueName: ExecutorQueueName,
+		Message:   string(message),
+		DedupeID:  typePtr(dedupeID),
+		GroupID:   typePtr(groupID),
+		Delay:     delay,
+	})
+}
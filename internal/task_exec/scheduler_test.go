@@ -0,0 +1,51 @@
+package taskexec
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stuckinforloop/ticker/database"
+	"github.com/stuckinforloop/ticker/internal/dao"
+	"github.com/stuckinforloop/ticker/internal/queue/broker"
+)
+
+func TestFireTask(t *testing.T) {
+	db := database.NewTestDB(t)
+
+	t.Run("overlapping-runs", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		now := time.Now().Unix()
+		d, err := dao.NewTestDAO(
+			dao.WithDB(db, db),
+			dao.WithTimeNow(func() int64 { return now }),
+		)
+		assert.NoError(t, err)
+
+		tk := createTestTask(t, d)
+		taskExecDAO := NewTaskExecDAO(d)
+		taskExecDAO.Queue = broker.NewMemory()
+		go taskExecDAO.RelayOutbox(ctx)
+
+		assert.NoError(t, taskExecDAO.fireTask(ctx, *tk, now-60))
+		assert.NoError(t, taskExecDAO.fireTask(ctx, *tk, now))
+
+		// the second run is delivered while the first one is still
+		// in flight, and both are allowed to start
+		for range 2 {
+			_, message, err := taskExecDAO.Queue.Dequeue(ctx, ExecutorQueueName, 300)
+			assert.NoError(t, err)
+
+			p := ExecutorPayload{}
+			assert.NoError(t, json.Unmarshal([]byte(message), &p))
+
+			action, err := taskExecDAO.claimSlot(ctx, p, 1, now)
+			assert.NoError(t, err)
+			assert.Equal(t, slotRun, action)
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package taskexec
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/spf13/viper"
+	"github.com/stuckinforloop/ticker/internal/lease"
+	"go.uber.org/zap"
+)
+
+// maxShards is the number of partitions tasks are spread over,
+// see the shard_key column of the tasks table
+const maxShards = 1024
+
+// ShardLeasePrefix prefixes the names of the shard leases
+const ShardLeasePrefix = "scheduler-shard-"
+
+// shardCount returns the number of shards configured by scheduler.shards.
+// Every scheduler instance must be configured with the same value.
+func shardCount() int {
+	count := viper.GetInt("scheduler.shards")
+	if count < 1 {
+		return 1
+	}
+
+	return min(count, maxShards)
+}
+
+func shardLease(count, shard int) string {
+	return fmt.Sprintf("%s%d-%d", ShardLeasePrefix, count, shard)
+}
+
+// assignShards spreads the shards round-robin over the instances
+// ordered by id and returns the shards assigned to self
+func assignShards(instances []string, self string, count int) map[int]bool {
+	if !slices.Contains(instances, self) {
+		instances = append(slices.Clone(instances), self)
+	}
+	slices.Sort(instances)
+
+	index := slices.Index(instances, self)
+	assigned := map[int]bool{}
+	for shard := 0; shard < count; shard++ {
+		if shard%len(instances) == index {
+			assigned[shard] = true
+		}
+	}
+
+	return assigned
+}
+
+// sharder claims the shards of the task space this scheduler instance
+// is responsible for. Shards are rebalanced when instances join or
+// leave, a shard is only taken over once its previous owner released
+// it or its lease expired.
+type sharder struct {
+	leaseDAO *lease.LeaseDAO
+	logger   *zap.Logger
+	count    int
+	owned    map[int]bool
+}
+
+// claim refreshes the heartbeat of this instance and acquires, renews or
+// releases shard leases to match its share. It returns the owned shards.
+func (s *sharder) claim(ctx context.Context) []int64 {
+	owned := map[int]bool{}
+	defer func() {
+		if !maps.Equal(owned, s.owned) {
+			s.logger.Info("Shards rebalanced",
+				zap.String("instance_id", InstanceID()),
+				zap.Int("shard_count", s.count),
+				zap.Int64s("shards", sortedShards(owned)))
+		}
+
+		s.owned = owned
+	}()
+
+	if err := s.leaseDAO.Heartbeat(ctx, InstanceID()); err != nil {
+		s.logger.Error("scheduler heartbeat", zap.Error(err))
+		return nil
+	}
+
+	ttl := leaseTTL()
+	instances, err := s.leaseDAO.ListInstances(ctx, ttl)
+	if err != nil {
+		s.logger.Error("list scheduler instances", zap.Error(err))
+		return nil
+	}
+
+	ids := make([]string, 0, len(instances))
+	for _, i := range instances {
+		ids = append(ids, i.ID)
+	}
+
+	assigned := assignShards(ids, InstanceID(), s.count)
+	for shard := 0; shard < s.count; shard++ {
+		name := shardLease(s.count, shard)
+		if !assigned[shard] {
+			if s.owned[shard] {
+				if err := s.leaseDAO.Release(ctx, name, InstanceID()); err != nil {
+					s.logger.Error("release shard lease", zap.Error(err), zap.Int("shard", shard))
+				}
+			}
+
+			continue
+		}
+
+		l, err := s.leaseDAO.Acquire(ctx, name, InstanceID(), ttl)
+		if err != nil {
+			s.logger.Error("acquire shard lease", zap.Error(err), zap.Int("shard", shard))
+			continue
+		}
+
+		if l != nil && l.Holder == InstanceID() {
+			owned[shard] = true
+		}
+	}
+
+	return sortedShards(owned)
+}
+
+// release gives up every owned shard and unregisters this instance
+func (s *sharder) release(ctx context.Context) {
+	for shard := range s.owned {
+		if err := s.leaseDAO.Release(ctx, shardLease(s.count, shard), InstanceID()); err != nil {
+			s.logger.Error("release shard lease", zap.Error(err), zap.Int("shard", shard))
+		}
+	}
+	s.owned = nil
+
+	if err := s.leaseDAO.DeleteInstance(ctx, InstanceID()); err != nil {
+		s.logger.Error("delete scheduler instance", zap.Error(err))
+	}
+}
+
+func sortedShards(owned map[int]bool) []int64 {
+	shards := make([]int64, 0, len(owned))
+	for shard := range owned {
+		shards = append(shards, int64(shard))
+	}
+	slices.Sort(shards)
+
+	return shards
+}
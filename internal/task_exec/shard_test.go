@@ -0,0 +1,30 @@
+package taskexec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignShards(t *testing.T) {
+	t.Run("single-instance", func(t *testing.T) {
+		assigned := assignShards(nil, "a", 3)
+		assert.Equal(t, map[int]bool{0: true, 1: true, 2: true}, assigned)
+	})
+
+	t.Run("round-robin", func(t *testing.T) {
+		instances := []string{"c", "a", "b"}
+		assert.Equal(t, map[int]bool{0: true, 3: true}, assignShards(instances, "a", 5))
+		assert.Equal(t, map[int]bool{1: true, 4: true}, assignShards(instances, "b", 5))
+		assert.Equal(t, map[int]bool{2: true}, assignShards(instances, "c", 5))
+	})
+
+	t.Run("joining-instance", func(t *testing.T) {
+		// self has not been listed as live yet
+		assert.Equal(t, map[int]bool{1: true, 3: true}, assignShards([]string{"a"}, "b", 4))
+	})
+
+	t.Run("more-instances-than-shards", func(t *testing.T) {
+		assert.Empty(t, assignShards([]string{"a", "b", "c"}, "c", 2))
+	})
+}
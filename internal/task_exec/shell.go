@@ -0,0 +1,95 @@
+package taskexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stuckinforloop/ticker/internal/task"
+)
+
+// shellWaitDelay is how long a killed command may keep its output
+// open, e.g. through a background process it started
+const shellWaitDelay = 5 * time.Second
+
+// shellExecutor runs the command of the task with /bin/sh and records
+// its exit code along with its output, stdout and stderr interleaved.
+// An exit code not in the exit codes of the task is returned along with
+// a *task.AssertionFailure.
+type shellExecutor struct{}
+
+func (shellExecutor) execute(ctx context.Context, p *ExecutorPayload) (*Response, error) {
+	if !task.ShellAllowed(p.TenantID) {
+		return nil, errors.New("shell tasks are not allowed for the tenant on this executor")
+	}
+
+	c := p.Shell
+	if c == nil {
+		return nil, errors.New("shell config is missing")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(*p.Timeout)*time.Second)
+	defer cancel()
+
+	out := newBody()
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", c.Command)
+	cmd.Dir = c.Dir
+	cmd.Env = shellEnv(c.Env)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.WaitDelay = shellWaitDelay
+
+	t := newTimer()
+	err := cmd.Run()
+
+	r := &Response{Timings: t.timings()}
+	out.record(r)
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		return r, fmt.Errorf("run command: %w", ctx.Err())
+	case errors.As(err, &exitErr) && exitErr.Exited():
+	default:
+		return r, fmt.Errorf("run command: %w", err)
+	}
+
+	code := cmd.ProcessState.ExitCode()
+	r.ExitCode = &code
+
+	if codes := c.SuccessExitCodes(); !slices.Contains(codes, code) {
+		expected := make([]string, len(codes))
+		for i, n := range codes {
+			expected[i] = strconv.Itoa(n)
+		}
+
+		return r, &task.AssertionFailure{
+			Assertion: "exit_codes",
+			Expected:  strings.Join(expected, ", "),
+			Actual:    strconv.Itoa(code),
+		}
+	}
+
+	return r, nil
+}
+
+// shellEnv returns the PATH of the executor followed by env as
+// KEY=value pairs in a stable order. The rest of the environment of
+// the executor, e.g. its database credentials, is not passed on.
+func shellEnv(env map[string]string) []string {
+	pairs := make([]string, 0, len(env))
+	for name, value := range env {
+		pairs = append(pairs, name+"="+value)
+	}
+	slices.Sort(pairs)
+
+	// a PATH of env comes last and wins
+	return append([]string{"PATH=" + os.Getenv("PATH")}, pairs...)
+}
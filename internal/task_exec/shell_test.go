@@ -0,0 +1,81 @@
+package taskexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stuckinforloop/ticker/internal/task"
+)
+
+func TestExecuteShell(t *testing.T) {
+	tenantID := "01J00000000000000000000001"
+	viper.Set("executor.shell.tenants", []string{tenantID})
+	defer viper.Set("executor.shell.tenants", nil)
+
+	execute := func(c *task.ShellConfig) (*Response, error) {
+		p := ExecutorPayload{TenantID: tenantID, Type: string(task.TypeShell), Timeout: typePtr(5), Shell: c}
+		return p.execute(context.Background())
+	}
+
+	t.Run("output", func(t *testing.T) {
+		resp, err := execute(&task.ShellConfig{
+			Command: `echo "$GREETING from $(pwd)"; echo oops >&2`,
+			Dir:     "/",
+			Env:     map[string]string{"GREETING": "hello"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, *resp.ExitCode)
+		assert.Equal(t, "hello from /\noops\n", resp.Body)
+	})
+
+	t.Run("exit-code", func(t *testing.T) {
+		resp, err := execute(&task.ShellConfig{Command: "exit 3"})
+		assert.Equal(t, 3, *resp.ExitCode)
+
+		var failure *task.AssertionFailure
+		assert.ErrorAs(t, err, &failure)
+		assert.Equal(t, "exit_codes", failure.Assertion)
+
+		_, err = execute(&task.ShellConfig{Command: "exit 3", ExitCodes: []int{0, 3}})
+		assert.NoError(t, err)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		p := ExecutorPayload{
+			TenantID: tenantID,
+			Type:     string(task.TypeShell),
+			Timeout:  typePtr(1),
+			Shell:    &task.ShellConfig{Command: "exec sleep 10"},
+		}
+		resp, err := p.execute(context.Background())
+		assert.Error(t, err)
+		assert.Nil(t, resp.ExitCode)
+	})
+
+	t.Run("environment", func(t *testing.T) {
+		t.Setenv("TICKER_SECRET", "hidden")
+
+		// only PATH and the env of the task are passed on
+		resp, err := execute(&task.ShellConfig{
+			Command: "env | sort",
+			Env:     map[string]string{"GREETING": "hello"},
+		})
+		assert.NoError(t, err)
+		assert.NotContains(t, resp.Body, "TICKER_SECRET")
+		assert.Contains(t, resp.Body, "GREETING=hello\n")
+		assert.Contains(t, resp.Body, "PATH=")
+	})
+
+	t.Run("other-tenant", func(t *testing.T) {
+		p := ExecutorPayload{
+			TenantID: "01J00000000000000000000002",
+			Type:     string(task.TypeShell),
+			Timeout:  typePtr(5),
+			Shell:    &task.ShellConfig{Command: "true"},
+		}
+		_, err := p.execute(context.Background())
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,53 @@
+package taskexec
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stuckinforloop/ticker/internal/task"
+)
+
+// sqlExecutor runs the statement of the task against the postgres
+// database configured under its dsn and records the number of rows
+// it affected
+type sqlExecutor struct{}
+
+func (sqlExecutor) execute(ctx context.Context, p *ExecutorPayload) (*Response, error) {
+	c := p.SQL
+	if c == nil {
+		return nil, errors.New("sql config is missing")
+	}
+
+	dsn, ok := task.SQLDSN(c.DSN, p.TenantID)
+	if !ok {
+		return nil, fmt.Errorf("dsn %s is not configured for the tenant on this executor", c.DSN)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(*p.Timeout)*time.Second)
+	defer cancel()
+
+	t := newTimer()
+
+	// a connection is opened per attempt, maintenance jobs run seldom
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", c.DSN, err)
+	}
+	defer db.Close()
+
+	res, err := db.ExecContext(ctx, c.Statement)
+	r := &Response{Timings: t.timings()}
+	if err != nil {
+		return r, fmt.Errorf("exec statement: %w", err)
+	}
+
+	// not every statement reports the rows it affected
+	if rows, err := res.RowsAffected(); err == nil {
+		r.RowsAffected = &rows
+	}
+
+	return r, nil
+}
@@ -0,0 +1,28 @@
+package taskexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stuckinforloop/ticker/internal/task"
+)
+
+func TestExecuteSQL(t *testing.T) {
+	viper.Set("executor.sql.dsns.maintenance.dsn", "postgres://localhost/app")
+	viper.Set("executor.sql.dsns.maintenance.tenants", []string{"01J00000000000000000000001"})
+	defer viper.Set("executor.sql.dsns.maintenance", nil)
+
+	t.Run("other-tenant", func(t *testing.T) {
+		// the dsn is configured, but not for the tenant of the task
+		p := ExecutorPayload{
+			TenantID: "01J00000000000000000000002",
+			Type:     string(task.TypeSQL),
+			Timeout:  typePtr(5),
+			SQL:      &task.SQLConfig{DSN: "maintenance", Statement: "SELECT 1"},
+		}
+		_, err := p.execute(context.Background())
+		assert.ErrorContains(t, err, "dsn maintenance is not configured for the tenant")
+	})
+}
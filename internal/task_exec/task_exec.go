@@ -1,15 +1,16 @@
 package taskexec
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"time"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/stuckinforloop/ticker/internal/task"
 )
 
 type Status string
@@ -17,97 +18,332 @@ type Status string
 const (
 	StatusPending   Status = "pending"
 	StatusRunning   Status = "running"
+	StatusRetrying  Status = "retrying"
 	StatusFailed    Status = "failed"
 	StatusCompleted Status = "completed"
+	StatusTimedOut  Status = "timed_out" // executor did not report back in time
+	StatusLost      Status = "lost"      // executor message was never picked up
+	StatusMissed    Status = "missed"    // run skipped while no scheduler was running
+	StatusSkipped   Status = "skipped"   // too many executions running (forbid)
+	StatusCancelled Status = "cancelled" // replaced by a newer execution (replace)
 )
 
+// Statuses lists every status of an execution
+var Statuses = []Status{
+	StatusPending, StatusRunning, StatusRetrying, StatusFailed, StatusCompleted,
+	StatusTimedOut, StatusLost, StatusMissed, StatusSkipped, StatusCancelled,
+}
+
+// finalStatuses are the statuses of executions that are over, executors
+// cannot change the status of an execution once it is final
+var finalStatuses = []Status{
+	StatusCompleted, StatusFailed, StatusTimedOut, StatusLost, StatusMissed,
+	StatusSkipped, StatusCancelled,
+}
+
+func (s Status) final() bool {
+	return slices.Contains(finalStatuses, s)
+}
+
 const (
 	ExecutorQueueName = "task-executor-1.fifo"
 	NotifierQueueName = "task-status-updates"
 )
 
 type TaskExec struct {
-	ID         string         `json:"id"`
-	TaskID     string         `json:"task_id"`
-	Status     Status         `json:"status"`
-	RunAt      int64          `json:"run_at"`
-	StartedAt  *int64         `json:"started_at"`
-	FinishedAt *int64         `json:"finished_at"`
-	Response   map[string]any `json:"response"`
-	CreatedAt  int64          `json:"created_at"`
-	UpdatedAt  int64          `json:"updated_at"`
+	ID         string    `json:"id"`
+	TenantID   string    `json:"tenant_id"`
+	TaskID     string    `json:"task_id"`
+	Status     Status    `json:"status"`
+	RunAt      int64     `json:"run_at"`
+	StartedAt  *int64    `json:"started_at"`
+	FinishedAt *int64    `json:"finished_at"`
+	Response   *Response `json:"response"`
+	Attempt    int       `json:"attempt"`
+	Attempts   []Attempt `json:"attempts"`
+	Error      *string   `json:"error"`
+	Reason     *string   `json:"reason"`
+	Manual     bool      `json:"manual"`
+	CreatedAt  int64     `json:"created_at"`
+	UpdatedAt  int64     `json:"updated_at"`
+
+	// FailedAssertion is the success criteria rule the response of
+	// the last attempt did not meet
+	FailedAssertion *task.AssertionFailure `json:"failed_assertion"`
+}
+
+// Attempt records the outcome of a single execution attempt of a TaskExec
+type Attempt struct {
+	Attempt    int     `json:"attempt"`
+	Status     Status  `json:"status"`
+	StartedAt  int64   `json:"started_at"`
+	FinishedAt int64   `json:"finished_at"`
+	Error      *string `json:"error"`
+	RetryAt    *int64  `json:"retry_at,omitempty"`
 }
 
 type ExecutorPayload struct {
-	TaskID           string         `json:"task_id"`
-	TaskExecID       string         `json:"task_exec_id"`
-	RunAt            int64          `json:"run_at"`
-	Timezone         string         `json:"timezone"`
-	Timeout          *int           `json:"timeout"`
-	Instances        *int           `json:"instances"`
-	URL              string         `json:"url"`
-	HTTPMethod       string         `json:"http_method"`
-	HTTPHeaders      map[string]any `json:"http_headers"`
-	PostData         map[string]any `json:"post_data"`
-	RetryAfter       *int           `json:"retry_after"`
-	FailureThreshold *int           `json:"failure_threshold"`
-	Notify           bool           `json:"notify"`
-	NotifyEvery      *int           `json:"notify_every"`
-}
-
-func (dao *TaskExecDAO) ListTaskExecs(
-	ctx context.Context, taskId string, limit int64, offset int64,
-) ([]TaskExec, error) {
+	TenantID          string         `json:"tenant_id"`
+	TaskID            string         `json:"task_id"`
+	TaskExecID        string         `json:"task_exec_id"`
+	RunAt             int64          `json:"run_at"`
+	Timezone          string         `json:"timezone"`
+	Timeout           *int           `json:"timeout"`
+	Instances         *int           `json:"instances"`
+	ConcurrencyPolicy string         `json:"concurrency_policy"`
+	Type              string         `json:"type"`
+	URL               string         `json:"url"`
+	HTTPMethod        string         `json:"http_method"`
+	HTTPHeaders       map[string]any `json:"http_headers"`
+	PostData          map[string]any `json:"post_data"`
+	RetryAfter        *int           `json:"retry_after"`
+	RetryBackoff      string         `json:"retry_backoff"`
+	MaxAttempts       *int           `json:"max_attempts"`
+	Attempt           int            `json:"attempt"`
+	FailureThreshold  *int           `json:"failure_threshold"`
+	Notify            bool           `json:"notify"`
+	NotifyEvery       *int           `json:"notify_every"`
+
+	SuccessCriteria *task.SuccessCriteria `json:"success_criteria"`
+	Shell           *task.ShellConfig     `json:"shell"`
+	SQL             *task.SQLConfig       `json:"sql"`
+}
+
+// columns lists the columns of the task_execs table in the order
+// expected by scanTaskExec
+const columns = `
+	id, tenant_id, task_id, status, run_at, started_at,
+	finished_at, response, attempt, attempts, error,
+	failed_assertion, reason, manual, created_at, updated_at
+`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTaskExec(row scanner) (*TaskExec, error) {
+	t := TaskExec{}
+	response := []byte{}
+	attempts := []byte{}
+	assertion := []byte{}
+	if err := row.Scan(
+		&t.ID, &t.TenantID, &t.TaskID, &t.Status, &t.RunAt,
+		&t.StartedAt, &t.FinishedAt, &response,
+		&t.Attempt, &attempts, &t.Error,
+		&assertion, &t.Reason, &t.Manual, &t.CreatedAt, &t.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if response != nil {
+		if err := json.Unmarshal(response, &t.Response); err != nil {
+			return nil, fmt.Errorf("unmarshal response: %w", err)
+		}
+	}
+
+	if attempts != nil {
+		if err := json.Unmarshal(attempts, &t.Attempts); err != nil {
+			return nil, fmt.Errorf("unmarshal attempts: %w", err)
+		}
+	}
+
+	if assertion != nil {
+		if err := json.Unmarshal(assertion, &t.FailedAssertion); err != nil {
+			return nil, fmt.Errorf("unmarshal failed assertion: %w", err)
+		}
+	}
+
+	return &t, nil
+}
+
+// ListFilter selects the executions returned by ListTaskExecs. Only
+// executions of TenantID are listed, other zero values do not filter.
+type ListFilter struct {
+	TenantID  string
+	TaskID    string
+	Statuses  []Status
+	RunAtFrom *int64 // inclusive
+	RunAtTo   *int64 // exclusive
+	Manual    *bool
+
+	// Cursor is the id of the last execution of the previous page
+	Cursor string
+	Limit  int
+}
+
+// ListTaskExecs returns the executions matching f, newest first. Pages
+// are keyed by the ULID of the executions, the returned cursor is empty
+// on the last page.
+func (dao *TaskExecDAO) ListTaskExecs(ctx context.Context, f ListFilter) ([]TaskExec, string, error) {
+	args := []any{}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{"tenant_id = " + arg(f.TenantID)}
+
+	if f.TaskID != "" {
+		conditions = append(conditions, "task_id = "+arg(f.TaskID))
+	}
+
+	if len(f.Statuses) > 0 {
+		conditions = append(conditions, "status = ANY("+arg(pq.Array(f.Statuses))+")")
+	}
+
+	if f.RunAtFrom != nil {
+		conditions = append(conditions, "run_at >= "+arg(*f.RunAtFrom))
+	}
+
+	if f.RunAtTo != nil {
+		conditions = append(conditions, "run_at < "+arg(*f.RunAtTo))
+	}
+
+	if f.Manual != nil {
+		conditions = append(conditions, "manual = "+arg(*f.Manual))
+	}
+
+	if f.Cursor != "" {
+		conditions = append(conditions, "id < "+arg(f.Cursor))
+	}
+
+	// one more row tells whether there is a next page
 	db := dao.RO()
 	query := `
-		SELECT
-			id, task_id, status, run_at, started_at,
-			finished_at, response, created_at, updated_at
+		SELECT ` + columns + `
 		FROM task_execs
-		WHERE task_id = $1
-		ORDER BY run_at DESC
-		LIMIT $2
-		OFFSET $3
-	`
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY id DESC
+		LIMIT ` + arg(f.Limit+1)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("list task_execs: %w", err)
+	}
+	defer rows.Close()
+
 	execs := []TaskExec{}
-	rows, err := db.QueryContext(ctx, query, taskId, limit, offset)
+	for rows.Next() {
+		t, err := scanTaskExec(rows)
+		if err != nil {
+			return nil, "", fmt.Errorf("scan row: %w", err)
+		}
+
+		execs = append(execs, *t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("rows iteration: %w", err)
+	}
+
+	cursor := ""
+	if len(execs) > f.Limit {
+		execs = execs[:f.Limit]
+		cursor = execs[len(execs)-1].ID
+	}
+
+	return execs, cursor, nil
+}
+
+// ListRecentTaskExecs returns the last limit executions of the task by
+// run time. Executions are not created in the order they run, e.g.
+// misfired runs are caught up after manual runs triggered meanwhile.
+func (dao *TaskExecDAO) ListRecentTaskExecs(ctx context.Context, tenantID, taskID string, limit int) ([]TaskExec, error) {
+	db := dao.RO()
+	query := `
+		SELECT ` + columns + `
+		FROM task_execs
+		WHERE tenant_id = $1 AND task_id = $2
+		ORDER BY run_at DESC, id DESC
+		LIMIT $3
+	`
+	rows, err := db.QueryContext(ctx, query, tenantID, taskID, limit)
 	if err != nil {
-		return nil, fmt.Errorf("list task_execs: %w", err)
+		return nil, fmt.Errorf("list recent task_execs: %w", err)
 	}
 	defer rows.Close()
 
+	execs := []TaskExec{}
 	for rows.Next() {
-		t := TaskExec{}
-		response := []byte{}
-		if err := rows.Scan(
-			&t.ID, &t.TaskID, &t.Status, &t.RunAt,
-			&t.StartedAt, &t.FinishedAt, &response,
-			&t.CreatedAt, &t.UpdatedAt,
-		); err != nil {
+		t, err := scanTaskExec(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scan row: %w", err)
 		}
 
-		if response != nil {
-			if err := json.Unmarshal(response, &t.Response); err != nil {
-				return nil, fmt.Errorf("unmarshal response: %w", err)
-			}
+		execs = append(execs, *t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration: %w", err)
+	}
+
+	return execs, nil
+}
+
+// GetTaskExec returns the execution of the tenant with the given id
+// or nil if it does not exist
+func (dao *TaskExecDAO) GetTaskExec(ctx context.Context, tenantID, id string) (*TaskExec, error) {
+	db := dao.RO()
+	query := `
+		SELECT ` + columns + `
+		FROM task_execs
+		WHERE id = $1 AND tenant_id = $2
+	`
+	t, err := scanTaskExec(db.QueryRowContext(ctx, query, id, tenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("get task_exec: %w", err)
+	}
+
+	return t, nil
+}
+
+// CountTaskExecs returns the number of executions of the tenant by
+// status. Only executions with run_at at or after since are counted.
+func (dao *TaskExecDAO) CountTaskExecs(ctx context.Context, tenantID string, since int64) (map[Status]int64, error) {
+	db := dao.RO()
+	query := `
+		SELECT status, COUNT(*)
+		FROM task_execs
+		WHERE tenant_id = $1 AND run_at >= $2
+		GROUP BY status
+	`
+	rows, err := db.QueryContext(ctx, query, tenantID, since)
+	if err != nil {
+		return nil, fmt.Errorf("count task_execs: %w", err)
+	}
+	defer rows.Close()
+
+	counts := map[Status]int64{}
+	for _, status := range Statuses {
+		counts[status] = 0
+	}
+
+	for rows.Next() {
+		var status Status
+		var count int64
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
 		}
 
-		execs = append(execs, t)
+		counts[status] = count
 	}
 
-	if rows.Err() != nil {
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("rows iteration: %w", err)
 	}
 
-	return execs, nil
+	return counts, nil
 }
 
 func (dao *TaskExecDAO) findTaskExec(ctx context.Context, taskId string, runAt int64) (*TaskExec, error) {
 	db := dao.RO()
 	query := `
 		SELECT id FROM task_execs
-		WHERE task_id = $1 AND run_at = $2
+		WHERE task_id = $1 AND run_at = $2 AND NOT manual
 	`
 	t := TaskExec{}
 	if err := db.QueryRowContext(ctx, query, taskId, runAt).Scan(&t.ID); err != nil {
@@ -121,23 +357,26 @@ func (dao *TaskExecDAO) findTaskExec(ctx context.Context, taskId string, runAt i
 	return &t, nil
 }
 
-func (dao *TaskExecDAO) createTaskExec(ctx context.Context, t *TaskExec) (*TaskExec, error) {
+// createTaskExec inserts the execution as part of tx
+func (dao *TaskExecDAO) createTaskExec(ctx context.Context, tx *sql.Tx, t *TaskExec) (*TaskExec, error) {
 	dao.fillDefaults(t)
 
 	t.CreatedAt = dao.TimeNow()
 	t.UpdatedAt = dao.TimeNow()
 
-	db := dao.RW()
 	query := `
 		INSERT INTO task_execs (
-			id, task_id, status, run_at, created_at, updated_at
+			id, tenant_id, task_id, status, run_at,
+			manual, created_at, updated_at
 		) Values (
-			$1, $2, $3, $4, $5, $6
+			$1, $2, $3, $4, $5,
+			$6, $7, $8
 		)
 	`
 
-	if _, err := db.ExecContext(ctx, query,
-		t.ID, t.TaskID, t.Status, t.RunAt, t.CreatedAt, t.UpdatedAt,
+	if _, err := tx.ExecContext(ctx, query,
+		t.ID, t.TenantID, t.TaskID, t.Status, t.RunAt,
+		t.Manual, t.CreatedAt, t.UpdatedAt,
 	); err != nil {
 		return nil, fmt.Errorf("create task_exec: %w", err)
 	}
@@ -145,12 +384,31 @@ func (dao *TaskExecDAO) createTaskExec(ctx context.Context, t *TaskExec) (*TaskE
 	return t, nil
 }
 
-func (dao *TaskExecDAO) updateTaskExec(ctx context.Context, t *TaskExec) error {
+// updateTaskExec writes the state reported by an executor. Attempts
+// carried by t are appended to the recorded attempt history. Executions
+// in a final status, or already past the attempt of t, are left
+// untouched, the returned bool reports whether the row was updated.
+func (dao *TaskExecDAO) updateTaskExec(ctx context.Context, t *TaskExec) (bool, error) {
 	updatedAt := dao.TimeNow()
 
 	resp, err := json.Marshal(t.Response)
 	if err != nil {
-		return fmt.Errorf("marshal response: %w", err)
+		return false, fmt.Errorf("marshal response: %w", err)
+	}
+
+	attempts := t.Attempts
+	if attempts == nil {
+		attempts = []Attempt{}
+	}
+
+	attemptsJSON, err := json.Marshal(attempts)
+	if err != nil {
+		return false, fmt.Errorf("marshal attempts: %w", err)
+	}
+
+	assertion, err := json.Marshal(t.FailedAssertion)
+	if err != nil {
+		return false, fmt.Errorf("marshal failed assertion: %w", err)
 	}
 
 	db := dao.RW()
@@ -159,69 +417,68 @@ func (dao *TaskExecDAO) updateTaskExec(ctx context.Context, t *TaskExec) error {
         SET
             status = $1,
             run_at = $2,
-            started_at = $3,
+            started_at = COALESCE(started_at, $3),
             finished_at = $4,
             response = $5,
-            updated_at = $6
-        WHERE id = $7
+            attempt = $6,
+            attempts = COALESCE(attempts, '[]'::jsonb) || $7::jsonb,
+            error = $8,
+            failed_assertion = $9,
+            updated_at = $10
+        WHERE id = $11 AND status <> ALL($12) AND attempt <= $6
     `
-	if _, err := db.ExecContext(ctx, query,
+	res, err := db.ExecContext(ctx, query,
 		t.Status,
 		t.RunAt,
 		t.StartedAt,
 		t.FinishedAt,
 		resp,
+		t.Attempt,
+		attemptsJSON,
+		t.Error,
+		assertion,
 		updatedAt,
 		t.ID,
-	); err != nil {
-		return fmt.Errorf("update task exec: %w", err)
+		pq.Array(finalStatuses),
+	)
+	if err != nil {
+		return false, fmt.Errorf("update task exec: %w", err)
 	}
 
-	return nil
-}
-
-func (p *ExecutorPayload) execute(ctx context.Context, now int64) ([]byte, error) {
-	runAt := time.Unix(p.RunAt, 0)
-	duration := runAt.Sub(time.Unix(now, 0))
-	time.Sleep(duration)
-
-	var req *http.Request
-	var err error
-
-	switch p.HTTPMethod {
-	case "GET", "HEAD", "DELETE":
-		req, err = http.NewRequestWithContext(ctx, p.HTTPMethod, p.URL, nil)
-		if err != nil {
-			return nil, fmt.Errorf("create request: %w", err)
-		}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("update task exec: %w", err)
+	}
 
-	case "POST", "PUT", "PATCH":
-		reqPayload, err := json.Marshal(p.PostData)
-		if err != nil {
-			return nil, fmt.Errorf("unmarshal post data: %w", err)
-		}
+	return updated > 0, nil
+}
 
-		req, err = http.NewRequestWithContext(ctx, p.HTTPMethod, p.URL, bytes.NewReader(reqPayload))
-		if err != nil {
-			return nil, fmt.Errorf("create request: %w", err)
-		}
-	}
+// executor runs an attempt of the tasks of one type. The outcome is
+// recorded in the returned response, also along with an error. A run
+// that completed without meeting the success rules of its task returns
+// a *task.AssertionFailure.
+type executor interface {
+	execute(ctx context.Context, p *ExecutorPayload) (*Response, error)
+}
 
-	client := http.Client{
-		Timeout: time.Duration(time.Second * time.Duration(*p.Timeout)),
-		// TODO: add support for sending cookies
-	}
+var executors = map[task.Type]executor{
+	task.TypeHTTP:  httpExecutor{},
+	task.TypeShell: shellExecutor{},
+	task.TypeSQL:   sqlExecutor{},
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("do request: %w", err)
+// execute runs the attempt with the executor of the type of the task
+func (p *ExecutorPayload) execute(ctx context.Context) (*Response, error) {
+	// messages enqueued before tasks had a type are http tasks
+	typ := task.Type(p.Type)
+	if typ == "" {
+		typ = task.TypeHTTP
 	}
-	defer resp.Body.Close()
 
-	respJSON, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return []byte(`{"response": ""}`), nil
+	e, ok := executors[typ]
+	if !ok {
+		return nil, fmt.Errorf("unsupported task type: %s", p.Type)
 	}
 
-	return respJSON, nil
+	return e.execute(ctx, p)
 }
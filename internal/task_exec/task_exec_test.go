@@ -0,0 +1,145 @@
+package taskexec
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stuckinforloop/ticker/database"
+	"github.com/stuckinforloop/ticker/internal/dao"
+	"github.com/stuckinforloop/ticker/internal/task"
+	"github.com/stuckinforloop/ticker/internal/tenant"
+)
+
+// createTestTask stores an http task of the default tenant running
+// every minute
+func createTestTask(t *testing.T, d *dao.DAO) *task.Task {
+	tk, err := task.NewTaskDAO(d, tenant.DefaultID).CreateTask(context.Background(), &task.Task{
+		Expression:        "* * * * *",
+		URL:               "http://localhost",
+		HTTPMethod:        "GET",
+		ConcurrencyPolicy: task.ConcurrencyAllow,
+	})
+	assert.NoError(t, err)
+
+	return tk
+}
+
+func TestListRecentTaskExecs(t *testing.T) {
+	db := database.NewTestDB(t)
+
+	t.Run("run-at-order", func(t *testing.T) {
+		ctx := context.Background()
+		d, err := dao.NewTestDAO(dao.WithDB(db, db))
+		assert.NoError(t, err)
+
+		tk := createTestTask(t, d)
+		taskExecDAO := NewTaskExecDAO(d)
+
+		// the execution created last runs first
+		execs := []TaskExec{
+			{ID: "01J00000000000000000000001", RunAt: 300},
+			{ID: "01J00000000000000000000002", RunAt: 200},
+			{ID: "01J00000000000000000000003", RunAt: 100},
+		}
+		err = d.WithTx(ctx, func(tx *sql.Tx) error {
+			for _, e := range execs {
+				e.TenantID = tk.TenantID
+				e.TaskID = tk.ID
+				e.Status = StatusCompleted
+				if _, err := taskExecDAO.createTaskExec(ctx, tx, &e); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		assert.NoError(t, err)
+
+		recent, err := taskExecDAO.ListRecentTaskExecs(ctx, tk.TenantID, tk.ID, 2)
+		assert.NoError(t, err)
+
+		runAts := []int64{}
+		for _, e := range recent {
+			runAts = append(runAts, e.RunAt)
+		}
+		assert.Equal(t, []int64{300, 200}, runAts)
+
+		// executions of other tenants are not listed
+		recent, err = taskExecDAO.ListRecentTaskExecs(ctx, "01J00000000000000000000000", tk.ID, 2)
+		assert.NoError(t, err)
+		assert.Empty(t, recent)
+	})
+}
+
+func TestGetTaskExec(t *testing.T) {
+	db := database.NewTestDB(t)
+
+	t.Run("other-tenant", func(t *testing.T) {
+		ctx := context.Background()
+		d, err := dao.NewTestDAO(dao.WithDB(db, db))
+		assert.NoError(t, err)
+
+		other, err := tenant.NewTenantDAO(d).CreateTenant(ctx, &tenant.Tenant{Name: "other"})
+		assert.NoError(t, err)
+
+		tk := createTestTask(t, d)
+		taskExecDAO := NewTaskExecDAO(d)
+
+		e := &TaskExec{TenantID: tk.TenantID, TaskID: tk.ID, Status: StatusPending, RunAt: 100}
+		err = d.WithTx(ctx, func(tx *sql.Tx) error {
+			e, err = taskExecDAO.createTaskExec(ctx, tx, e)
+			return err
+		})
+		assert.NoError(t, err)
+
+		got, err := taskExecDAO.GetTaskExec(ctx, tk.TenantID, e.ID)
+		assert.NoError(t, err)
+		assert.NotNil(t, got)
+
+		got, err = taskExecDAO.GetTaskExec(ctx, other.ID, e.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+func TestUpdateTaskExec(t *testing.T) {
+	db := database.NewTestDB(t)
+
+	t.Run("stale-attempt", func(t *testing.T) {
+		ctx := context.Background()
+		d, err := dao.NewTestDAO(dao.WithDB(db, db))
+		assert.NoError(t, err)
+
+		tk := createTestTask(t, d)
+		taskExecDAO := NewTaskExecDAO(d)
+
+		e := &TaskExec{TenantID: tk.TenantID, TaskID: tk.ID, Status: StatusPending, RunAt: 100}
+		err = d.WithTx(ctx, func(tx *sql.Tx) error {
+			e, err = taskExecDAO.createTaskExec(ctx, tx, e)
+			return err
+		})
+		assert.NoError(t, err)
+
+		// the second attempt reports before the retry of the first one
+		updated, err := taskExecDAO.updateTaskExec(ctx, &TaskExec{
+			ID: e.ID, Status: StatusRunning, RunAt: 200, Attempt: 2,
+		})
+		assert.NoError(t, err)
+		assert.True(t, updated)
+
+		retryAt := int64(200)
+		updated, err = taskExecDAO.updateTaskExec(ctx, &TaskExec{
+			ID: e.ID, Status: StatusRetrying, RunAt: 100, Attempt: 1,
+			Attempts: []Attempt{{Attempt: 1, Status: StatusRetrying, RetryAt: &retryAt}},
+		})
+		assert.NoError(t, err)
+		assert.False(t, updated)
+
+		got, err := taskExecDAO.GetTaskExec(ctx, tk.TenantID, e.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, StatusRunning, got.Status)
+		assert.Equal(t, 2, got.Attempt)
+	})
+}
@@ -0,0 +1,13 @@
+package tenant
+
+import "github.com/stuckinforloop/ticker/internal/dao"
+
+type TenantDAO struct {
+	*dao.DAO
+}
+
+func NewTenantDAO(dao *dao.DAO) *TenantDAO {
+	return &TenantDAO{
+		dao,
+	}
+}
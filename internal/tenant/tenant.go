@@ -0,0 +1,254 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/stuckinforloop/ticker/internal/task"
+)
+
+type Status string
+
+const (
+	StatusActive  Status = "active"
+	StatusExpired Status = "expired" // tasks do not run and api keys are rejected
+)
+
+// DefaultID is the tenant of the rows created before tenants were
+// introduced and of API requests while authentication is disabled
+const DefaultID = "00000000000000000000000000"
+
+// Tenant owns groups, tasks, executions and api keys. Nothing
+// is shared between tenants.
+type Tenant struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Status    Status `json:"status"`
+	ExpiresAt *int64 `json:"expires_at"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+func (dao *TenantDAO) CreateTenant(ctx context.Context, t *Tenant) (*Tenant, error) {
+	t.ID = dao.ULIDSource.New(uint64(dao.TimeNow()))
+	t.Status = StatusActive
+	t.CreatedAt = dao.TimeNow()
+	t.UpdatedAt = dao.TimeNow()
+
+	db := dao.RW()
+	query := `
+		INSERT INTO tenants (
+			id, name, status, expires_at, created_at, updated_at
+		) Values (
+			$1, $2, $3, $4, $5, $6
+		)
+	`
+	if _, err := db.ExecContext(ctx, query,
+		t.ID, t.Name, t.Status, t.ExpiresAt, t.CreatedAt, t.UpdatedAt,
+	); err != nil {
+		return nil, fmt.Errorf("create tenant: %w", err)
+	}
+
+	return t, nil
+}
+
+// GetTenant returns the tenant with the given id or nil if it does not exist
+func (dao *TenantDAO) GetTenant(ctx context.Context, id string) (*Tenant, error) {
+	db := dao.RO()
+	query := `
+		SELECT id, name, status, expires_at, created_at, updated_at
+		FROM tenants
+		WHERE id = $1
+	`
+	t := Tenant{}
+	if err := db.QueryRowContext(ctx, query, id).Scan(
+		&t.ID, &t.Name, &t.Status, &t.ExpiresAt, &t.CreatedAt, &t.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("get tenant: %w", err)
+	}
+
+	return &t, nil
+}
+
+func (dao *TenantDAO) ListTenants(ctx context.Context) ([]Tenant, error) {
+	db := dao.RO()
+	query := `
+		SELECT id, name, status, expires_at, created_at, updated_at
+		FROM tenants
+		ORDER BY id
+	`
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list tenants: %w", err)
+	}
+	defer rows.Close()
+
+	tenants := []Tenant{}
+	for rows.Next() {
+		t := Tenant{}
+		if err := rows.Scan(
+			&t.ID, &t.Name, &t.Status, &t.ExpiresAt, &t.CreatedAt, &t.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		tenants = append(tenants, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration: %w", err)
+	}
+
+	return tenants, nil
+}
+
+// ExpireTenant expires the tenant along with its active tasks and
+// reports whether an active tenant was expired. Disabled tasks stay
+// disabled, they are not resumed while the tenant is expired.
+func (dao *TenantDAO) ExpireTenant(ctx context.Context, id string) (bool, error) {
+	expired := false
+	err := dao.WithTx(ctx, func(tx *sql.Tx) error {
+		now := dao.TimeNow()
+		query := `
+			UPDATE tenants
+			SET
+				status = $1,
+				updated_at = $2
+			WHERE id = $3 AND status = $4
+		`
+		res, err := tx.ExecContext(ctx, query, StatusExpired, now, id, StatusActive)
+		if err != nil {
+			return fmt.Errorf("expire tenant: %w", err)
+		}
+
+		updated, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("expire tenant: %w", err)
+		}
+
+		expired = updated > 0
+		if !expired {
+			return nil
+		}
+
+		query = `
+			UPDATE tasks
+			SET
+				status = $1,
+				updated_at = $2
+			WHERE tenant_id = $3 AND status = $4
+		`
+		if _, err := tx.ExecContext(ctx, query,
+			task.StatusExpired, now, id, task.StatusActive,
+		); err != nil {
+			return fmt.Errorf("expire tasks: %w", err)
+		}
+
+		return nil
+	})
+
+	return expired, err
+}
+
+// ActivateTenant re-activates an expired tenant along with its expired
+// tasks and reports whether an expired tenant was activated. Runs missed
+// while the tenant was expired are not caught up. The tenant expires
+// again at expiresAt, nil for never.
+func (dao *TenantDAO) ActivateTenant(ctx context.Context, id string, expiresAt *int64) (bool, error) {
+	activated := false
+	err := dao.WithTx(ctx, func(tx *sql.Tx) error {
+		now := dao.TimeNow()
+		query := `
+			UPDATE tenants
+			SET
+				status = $1,
+				expires_at = $2,
+				updated_at = $3
+			WHERE id = $4 AND status = $5
+		`
+		res, err := tx.ExecContext(ctx, query, StatusActive, expiresAt, now, id, StatusExpired)
+		if err != nil {
+			return fmt.Errorf("activate tenant: %w", err)
+		}
+
+		updated, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("activate tenant: %w", err)
+		}
+
+		activated = updated > 0
+		if !activated {
+			return nil
+		}
+
+		// the scheduler computes the next run of tasks without next_run_at
+		query = `
+			UPDATE tasks
+			SET
+				status = $1,
+				next_run_at = NULL,
+				updated_at = $2
+			WHERE tenant_id = $3 AND status = $4
+		`
+		if _, err := tx.ExecContext(ctx, query,
+			task.StatusActive, now, id, task.StatusExpired,
+		); err != nil {
+			return fmt.Errorf("activate tasks: %w", err)
+		}
+
+		return nil
+	})
+
+	return activated, err
+}
+
+// ExpireDueTenants expires the active tenants whose expires_at has
+// passed and returns how many tenants were expired
+func (dao *TenantDAO) ExpireDueTenants(ctx context.Context) (int64, error) {
+	db := dao.RO()
+	query := `
+		SELECT id
+		FROM tenants
+		WHERE status = $1 AND expires_at <= $2
+	`
+	rows, err := db.QueryContext(ctx, query, StatusActive, dao.TimeNow())
+	if err != nil {
+		return 0, fmt.Errorf("get due tenants: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return 0, fmt.Errorf("scan row: %w", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("rows iteration: %w", err)
+	}
+	rows.Close()
+
+	var expired int64
+	for _, id := range ids {
+		ok, err := dao.ExpireTenant(ctx, id)
+		if err != nil {
+			return expired, err
+		}
+
+		if ok {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,106 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stuckinforloop/ticker/database"
+	"github.com/stuckinforloop/ticker/internal/dao"
+	"github.com/stuckinforloop/ticker/internal/task"
+)
+
+func TestExpireTenant(t *testing.T) {
+	db := database.NewTestDB(t)
+	ctx := context.Background()
+	now := int64(1700000000)
+
+	d, err := dao.NewTestDAO(
+		dao.WithDB(db, db),
+		dao.WithTimeNow(func() int64 { return now }),
+	)
+	assert.NoError(t, err)
+	tenantDAO := NewTenantDAO(d)
+
+	tn, err := tenantDAO.CreateTenant(ctx, &Tenant{Name: "expire"})
+	assert.NoError(t, err)
+
+	taskDAO := task.NewTaskDAO(d, tn.ID)
+	defaultDAO := task.NewTaskDAO(d, DefaultID)
+	createTask := func(dao *task.TaskDAO) *task.Task {
+		tk, err := dao.CreateTask(ctx, &task.Task{
+			Expression: "* * * * *",
+			URL:        "http://localhost",
+			HTTPMethod: "GET",
+		})
+		assert.NoError(t, err)
+
+		return tk
+	}
+
+	active := createTask(taskDAO)
+	paused := createTask(taskDAO)
+	_, err = taskDAO.PauseTask(ctx, paused.ID, task.Pause{})
+	assert.NoError(t, err)
+	other := createTask(defaultDAO)
+
+	assertStatus := func(t *testing.T, dao *task.TaskDAO, id string, status task.Status) *task.Task {
+		tk, err := dao.GetTask(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, status, tk.Status)
+
+		return tk
+	}
+
+	t.Run("expire", func(t *testing.T) {
+		expired, err := tenantDAO.ExpireTenant(ctx, tn.ID)
+		assert.NoError(t, err)
+		assert.True(t, expired)
+
+		got, err := tenantDAO.GetTenant(ctx, tn.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, StatusExpired, got.Status)
+
+		assertStatus(t, taskDAO, active.ID, task.StatusExpired)
+		assertStatus(t, taskDAO, paused.ID, task.StatusDisabled)
+		assertStatus(t, defaultDAO, other.ID, task.StatusActive)
+
+		// expiring an expired tenant is a no-op
+		expired, err = tenantDAO.ExpireTenant(ctx, tn.ID)
+		assert.NoError(t, err)
+		assert.False(t, expired)
+	})
+
+	t.Run("activate", func(t *testing.T) {
+		expiresAt := now + 3600
+		activated, err := tenantDAO.ActivateTenant(ctx, tn.ID, &expiresAt)
+		assert.NoError(t, err)
+		assert.True(t, activated)
+
+		got, err := tenantDAO.GetTenant(ctx, tn.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, StatusActive, got.Status)
+		assert.Equal(t, &expiresAt, got.ExpiresAt)
+
+		// the next run is computed again by the scheduler
+		tk := assertStatus(t, taskDAO, active.ID, task.StatusActive)
+		assert.Nil(t, tk.NextRunAt)
+		assertStatus(t, taskDAO, paused.ID, task.StatusDisabled)
+		assertStatus(t, defaultDAO, other.ID, task.StatusActive)
+
+		activated, err = tenantDAO.ActivateTenant(ctx, tn.ID, nil)
+		assert.NoError(t, err)
+		assert.False(t, activated)
+	})
+
+	t.Run("expire-due", func(t *testing.T) {
+		now += 3600
+		defer func() { now -= 3600 }()
+
+		expired, err := tenantDAO.ExpireDueTenants(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), expired)
+
+		assertStatus(t, taskDAO, active.ID, task.StatusExpired)
+	})
+}
@@ -1,6 +1,11 @@
 package main
 
-import "github.com/stuckinforloop/ticker/cmd"
+import (
+	// embed the timezone database for tasks scheduled in IANA timezones
+	_ "time/tzdata"
+
+	"github.com/stuckinforloop/ticker/cmd"
+)
 
 func main() {
 	cmd.Execute()
@@ -0,0 +1,243 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stuckinforloop/ticker/internal/task"
+	"go.uber.org/zap"
+)
+
+type GroupRequest struct {
+	Name string `json:"name"`
+}
+
+func parseGroupRequest(r *http.Request) (*GroupRequest, error) {
+	payload := &GroupRequest{}
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		return nil, err
+	}
+
+	payload.Name = strings.TrimSpace(payload.Name)
+	if payload.Name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	return payload, nil
+}
+
+func (a *API) CreateGroup(w http.ResponseWriter, r *http.Request) *Response {
+	taskDAO := task.NewTaskDAO(a.dao, tenantID(r))
+
+	payload, err := parseGroupRequest(r)
+	if err != nil {
+		return &Response{
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+
+	g, err := taskDAO.CreateGroup(r.Context(), &task.Group{Name: payload.Name})
+	if err != nil {
+		a.dao.Logger.Error("create group", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	return &Response{
+		StatusCode: http.StatusCreated,
+		Data:       g,
+	}
+}
+
+type GetGroupsResponse struct {
+	Groups []task.Group `json:"groups"`
+}
+
+func (a *API) GetGroups(w http.ResponseWriter, r *http.Request) *Response {
+	taskDAO := task.NewTaskDAO(a.dao, tenantID(r))
+
+	groups, err := taskDAO.GetGroups(r.Context())
+	if err != nil {
+		a.dao.Logger.Error("get groups", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	return &Response{
+		StatusCode: http.StatusOK,
+		Data:       GetGroupsResponse{Groups: groups},
+	}
+}
+
+func (a *API) GetGroup(w http.ResponseWriter, r *http.Request) *Response {
+	g, resp := a.group(r)
+	if resp != nil {
+		return resp
+	}
+
+	return &Response{
+		StatusCode: http.StatusOK,
+		Data:       g,
+	}
+}
+
+func (a *API) RenameGroup(w http.ResponseWriter, r *http.Request) *Response {
+	taskDAO := task.NewTaskDAO(a.dao, tenantID(r))
+
+	payload, err := parseGroupRequest(r)
+	if err != nil {
+		return &Response{
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+
+	g, err := taskDAO.RenameGroup(r.Context(), chi.URLParam(r, "id"), payload.Name)
+	if err != nil {
+		a.dao.Logger.Error("rename group", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	if g == nil {
+		return &Response{
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New("group not found"),
+		}
+	}
+
+	return &Response{
+		StatusCode: http.StatusOK,
+		Data:       g,
+	}
+}
+
+// DeleteGroup deletes the group, its tasks are kept without a group
+func (a *API) DeleteGroup(w http.ResponseWriter, r *http.Request) *Response {
+	taskDAO := task.NewTaskDAO(a.dao, tenantID(r))
+
+	if err := taskDAO.DeleteGroup(r.Context(), chi.URLParam(r, "id")); err != nil {
+		a.dao.Logger.Error("delete group", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	return &Response{
+		StatusCode: http.StatusOK,
+	}
+}
+
+// GetGroupTasks lists the tasks of the group, it supports the
+// same query parameters as GetTasks
+func (a *API) GetGroupTasks(w http.ResponseWriter, r *http.Request) *Response {
+	g, resp := a.group(r)
+	if resp != nil {
+		return resp
+	}
+
+	q := r.URL.Query()
+	q.Set("group_id", g.ID)
+	r.URL.RawQuery = q.Encode()
+
+	return a.GetTasks(w, r)
+}
+
+type GroupTasksResponse struct {
+	Affected int64 `json:"affected"`
+}
+
+// PauseGroup pauses every active task of the group, see PauseTask
+func (a *API) PauseGroup(w http.ResponseWriter, r *http.Request) *Response {
+	p, err := a.parsePause(r)
+	if err != nil {
+		return &Response{
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+
+	return a.groupTasks(r, "pause group",
+		func(dao *task.TaskDAO, ctx context.Context, id string) (int64, error) {
+			return dao.PauseGroup(ctx, id, *p)
+		})
+}
+
+// ResumeGroup enables the tasks paused along with the group
+func (a *API) ResumeGroup(w http.ResponseWriter, r *http.Request) *Response {
+	return a.groupTasks(r, "resume group", (*task.TaskDAO).ResumeGroup)
+}
+
+// DeleteGroupTasks deletes every task of the group
+func (a *API) DeleteGroupTasks(w http.ResponseWriter, r *http.Request) *Response {
+	return a.groupTasks(r, "delete group tasks", (*task.TaskDAO).DeleteGroupTasks)
+}
+
+// groupTasks applies a bulk operation to the tasks of the group
+func (a *API) groupTasks(
+	r *http.Request, op string,
+	apply func(dao *task.TaskDAO, ctx context.Context, id string) (int64, error),
+) *Response {
+	taskDAO := task.NewTaskDAO(a.dao, tenantID(r))
+
+	g, resp := a.group(r)
+	if resp != nil {
+		return resp
+	}
+
+	affected, err := apply(taskDAO, r.Context(), g.ID)
+	if err != nil {
+		a.dao.Logger.Error(op, zap.Error(err), zap.String("group_id", g.ID))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	return &Response{
+		StatusCode: http.StatusOK,
+		Data:       GroupTasksResponse{Affected: affected},
+	}
+}
+
+// group returns the group of the request, or the response to send
+// if it cannot be found
+func (a *API) group(r *http.Request) (*task.Group, *Response) {
+	taskDAO := task.NewTaskDAO(a.dao, tenantID(r))
+
+	g, err := taskDAO.GetGroup(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		a.dao.Logger.Error("get group", zap.Error(err))
+
+		return nil, &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	if g == nil {
+		return nil, &Response{
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New("group not found"),
+		}
+	}
+
+	return g, nil
+}
@@ -16,21 +16,38 @@ type Response struct {
 	Err        error       `json:"error,omitempty"`
 }
 
-func WithResponse(handler func(w http.ResponseWriter, r *http.Request) *Response) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		response := handler(w, r)
+// MarshalJSON writes Err as its message, errors have no exported
+// fields and would otherwise be written as {}
+func (r Response) MarshalJSON() ([]byte, error) {
+	type response Response
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(response.StatusCode)
+	var message string
+	if r.Err != nil {
+		message = r.Err.Error()
+	}
+
+	return json.Marshal(struct {
+		response
+		Err string `json:"error,omitempty"`
+	}{response(r), message})
+}
 
-		data, err := json.MarshalIndent(response, "", " ")
-		if err != nil {
-			http.Error(w, "failed to marshal json response", http.StatusInternalServerError)
-			return
-		}
+func WithResponse(handler func(w http.ResponseWriter, r *http.Request) *Response) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, handler(w, r))
+	}
+}
 
-		w.Write(data)
+func writeResponse(w http.ResponseWriter, response *Response) {
+	data, err := json.MarshalIndent(response, "", " ")
+	if err != nil {
+		http.Error(w, "failed to marshal json response", http.StatusInternalServerError)
+		return
 	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+	w.Write(data)
 }
 
 func (a *API) RegisterRoutes() {
@@ -49,8 +66,8 @@ func (a *API) RegisterRoutes() {
 
 	a.mux.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   allowedOrigins,
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-API-Key", "X-CSRF-Token"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: false,
 		MaxAge:           300,
@@ -60,14 +77,55 @@ func (a *API) RegisterRoutes() {
 		r.Get("/ping", WithResponse(a.Ping))
 	})
 
-	a.mux.Route("/tasks", func(r chi.Router) {
-		r.Get("/", WithResponse(a.GetTasks))
-		r.Post("/", WithResponse(a.CreateTask))
+	// every other route needs an API key
+	a.mux.Group(func(r chi.Router) {
+		r.Use(WithAuth(a.dao))
 
-		r.Route("/{id}", func(r chi.Router) {
-			r.Get("/", WithResponse(a.GetTask))
-			r.Patch("/", WithResponse(a.UpdateTask))
-			r.Delete("/", WithResponse(a.DeleteTask))
+		r.Route("/scheduler", func(r chi.Router) {
+			r.Use(WithDefaultTenant)
+
+			r.Get("/leader", WithResponse(a.GetLeader))
+			r.Get("/shards", WithResponse(a.GetShards))
+		})
+
+		r.Route("/tasks", func(r chi.Router) {
+			r.Get("/", WithResponse(a.GetTasks))
+			r.Post("/", WithResponse(a.CreateTask))
+
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", WithResponse(a.GetTask))
+				r.Patch("/", WithResponse(a.UpdateTask))
+				r.Put("/", WithResponse(a.ReplaceTask))
+				r.Delete("/", WithResponse(a.DeleteTask))
+				r.Post("/enable", WithResponse(a.EnableTask))
+				r.Post("/pause", WithResponse(a.PauseTask))
+				r.Post("/resume", WithResponse(a.ResumeTask))
+				r.Post("/run", WithResponse(a.RunTask))
+				r.Get("/executions", WithResponse(a.GetTaskTaskExecs))
+			})
+		})
+
+		r.Route("/groups", func(r chi.Router) {
+			r.Get("/", WithResponse(a.GetGroups))
+			r.Post("/", WithResponse(a.CreateGroup))
+
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", WithResponse(a.GetGroup))
+				r.Patch("/", WithResponse(a.RenameGroup))
+				r.Delete("/", WithResponse(a.DeleteGroup))
+				r.Get("/tasks", WithResponse(a.GetGroupTasks))
+				r.Delete("/tasks", WithResponse(a.DeleteGroupTasks))
+				r.Post("/pause", WithResponse(a.PauseGroup))
+				r.Post("/resume", WithResponse(a.ResumeGroup))
+			})
 		})
+
+		r.Route("/executions", func(r chi.Router) {
+			r.Get("/", WithResponse(a.GetTaskExecs))
+			r.Get("/stats", WithResponse(a.GetTaskExecStats))
+			r.Get("/{id}", WithResponse(a.GetTaskExec))
+		})
+
+		r.Get("/usage", WithResponse(a.GetUsage))
 	})
 }
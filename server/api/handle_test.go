@@ -0,0 +1,30 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseMarshalJSON(t *testing.T) {
+	t.Run("error", func(t *testing.T) {
+		data, err := json.Marshal(&Response{
+			StatusCode: http.StatusBadRequest,
+			Err:        errors.New("invalid expression"),
+		})
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"status_code": 400, "error": "invalid expression"}`, string(data))
+	})
+
+	t.Run("data", func(t *testing.T) {
+		data, err := json.Marshal(&Response{
+			StatusCode: http.StatusOK,
+			Data:       map[string]int{"affected": 2},
+		})
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"status_code": 200, "response": {"affected": 2}}`, string(data))
+	})
+}
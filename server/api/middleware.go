@@ -2,10 +2,16 @@ package api
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/spf13/viper"
+	"github.com/stuckinforloop/ticker/internal/apikey"
+	"github.com/stuckinforloop/ticker/internal/dao"
+	"github.com/stuckinforloop/ticker/internal/tenant"
 	"go.uber.org/zap"
 )
 
@@ -14,6 +20,7 @@ type contextKey string
 const (
 	ContextKeyLogger    contextKey = "logger"
 	ContextKeyRequestID contextKey = "request_id"
+	ContextKeyAPIKey    contextKey = "api_key"
 )
 
 func WithLogger(logger *zap.Logger) func(next http.Handler) http.Handler {
@@ -42,3 +49,104 @@ func WithLogger(logger *zap.Logger) func(next http.Handler) http.Handler {
 		return http.HandlerFunc(fn)
 	}
 }
+
+// WithAuth rejects requests without a valid API key, passed as a bearer
+// token or in the X-API-Key header. GET and HEAD requests need a key with
+// the read scope, other requests the write scope. Setting auth.disabled
+// turns authentication off, e.g. for local development.
+func WithAuth(dao *dao.DAO) func(next http.Handler) http.Handler {
+	apiKeyDAO := apikey.NewAPIKeyDAO(dao)
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if viper.GetBool("auth.disabled") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := r.Header.Get("X-API-Key")
+			if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+				key = bearer
+			}
+
+			if key == "" {
+				writeResponse(w, &Response{
+					StatusCode: http.StatusUnauthorized,
+					Err:        errors.New("api key is required"),
+				})
+				return
+			}
+
+			k, err := apiKeyDAO.Authenticate(r.Context(), key)
+			if err != nil {
+				dao.Logger.Error("authenticate api key", zap.Error(err))
+
+				writeResponse(w, &Response{
+					StatusCode: http.StatusInternalServerError,
+					Err:        err,
+				})
+				return
+			}
+
+			if k == nil {
+				writeResponse(w, &Response{
+					StatusCode: http.StatusUnauthorized,
+					Err:        errors.New("invalid api key"),
+				})
+				return
+			}
+
+			scope := apikey.ScopeWrite
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				scope = apikey.ScopeRead
+			}
+
+			if !k.Allows(scope) {
+				writeResponse(w, &Response{
+					StatusCode: http.StatusForbidden,
+					Err:        errors.New("api key is read-only"),
+				})
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), ContextKeyAPIKey, k)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// WithDefaultTenant rejects requests of other tenants than the default
+// one. It guards the routes exposing the state of the whole cluster,
+// e.g. the scheduler instances, which tenants must not see.
+func WithDefaultTenant(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if tenantID(r) != tenant.DefaultID {
+			writeResponse(w, &Response{
+				StatusCode: http.StatusForbidden,
+				Err:        errors.New("only keys of the default tenant may access the scheduler"),
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// apiKey returns the key that authenticated the request, nil
+// if authentication is disabled
+func apiKey(r *http.Request) *apikey.APIKey {
+	k, _ := r.Context().Value(ContextKeyAPIKey).(*apikey.APIKey)
+	return k
+}
+
+// tenantID returns the tenant the request acts on, the tenant of its
+// api key or the default tenant if authentication is disabled
+func tenantID(r *http.Request) string {
+	if k := apiKey(r); k != nil {
+		return k.TenantID
+	}
+
+	return tenant.DefaultID
+}
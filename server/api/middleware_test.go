@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stuckinforloop/ticker/database"
+	"github.com/stuckinforloop/ticker/internal/apikey"
+	"github.com/stuckinforloop/ticker/internal/dao"
+	"github.com/stuckinforloop/ticker/internal/tenant"
+)
+
+func TestWithAuth(t *testing.T) {
+	db := database.NewTestDB(t)
+	now := int64(1700000000)
+
+	newDAO := func(t *testing.T, rw *sql.DB) *dao.DAO {
+		d, err := dao.NewTestDAO(
+			dao.WithDB(db, rw),
+			dao.WithTimeNow(func() int64 { return now }),
+		)
+		assert.NoError(t, err)
+
+		return d
+	}
+	d := newDAO(t, db)
+	apiKeyDAO := apikey.NewAPIKeyDAO(d)
+
+	createKey := func(t *testing.T, scope apikey.Scope, expiresAt *int64) (*apikey.APIKey, string) {
+		k, key, err := apiKeyDAO.CreateKey(context.Background(), tenant.DefaultID, "test", scope, expiresAt)
+		assert.NoError(t, err)
+
+		return k, key
+	}
+	_, readKey := createKey(t, apikey.ScopeRead, nil)
+	_, writeKey := createKey(t, apikey.ScopeWrite, nil)
+
+	// serve returns the status code of a request authenticated with key
+	serve := func(d *dao.DAO, method, key string) int {
+		handler := WithAuth(d)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		r := httptest.NewRequest(method, "/tasks", nil)
+		if key != "" {
+			r.Header.Set("Authorization", "Bearer "+key)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	t.Run("missing-key", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve(d, http.MethodGet, ""))
+	})
+
+	t.Run("invalid-key", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve(d, http.MethodGet, "not-a-key"))
+		assert.Equal(t, http.StatusUnauthorized, serve(d, http.MethodGet, "tk_unknown"))
+	})
+
+	t.Run("read-key", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(d, http.MethodGet, readKey))
+		assert.Equal(t, http.StatusForbidden, serve(d, http.MethodPost, readKey))
+	})
+
+	t.Run("write-key", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(d, http.MethodGet, writeKey))
+		assert.Equal(t, http.StatusOK, serve(d, http.MethodPost, writeKey))
+	})
+
+	t.Run("x-api-key", func(t *testing.T) {
+		handler := WithAuth(d)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.NotNil(t, apiKey(r))
+		}))
+
+		r := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		r.Header.Set("X-API-Key", readKey)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("revoked-key", func(t *testing.T) {
+		k, key := createKey(t, apikey.ScopeWrite, nil)
+
+		revoked, err := apiKeyDAO.RevokeKey(context.Background(), k.ID)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+
+		assert.Equal(t, http.StatusUnauthorized, serve(d, http.MethodGet, key))
+	})
+
+	t.Run("expired-key", func(t *testing.T) {
+		expiresAt := now + 60
+		_, key := createKey(t, apikey.ScopeWrite, &expiresAt)
+		assert.Equal(t, http.StatusOK, serve(d, http.MethodGet, key))
+
+		now += 60
+		defer func() { now -= 60 }()
+
+		assert.Equal(t, http.StatusUnauthorized, serve(d, http.MethodGet, key))
+	})
+
+	t.Run("last-used-at-failure", func(t *testing.T) {
+		_, key := createKey(t, apikey.ScopeRead, nil)
+
+		// the primary cannot be written to
+		rw, err := sql.Open("postgres", "")
+		assert.NoError(t, err)
+		assert.NoError(t, rw.Close())
+
+		assert.Equal(t, http.StatusOK, serve(newDAO(t, rw), http.MethodGet, key))
+	})
+}
+
+func TestWithDefaultTenant(t *testing.T) {
+	handler := WithDefaultTenant(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(k *apikey.APIKey) int {
+		r := httptest.NewRequest(http.MethodGet, "/scheduler/leader", nil)
+		if k != nil {
+			r = r.WithContext(context.WithValue(r.Context(), ContextKeyAPIKey, k))
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	t.Run("default-tenant", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(&apikey.APIKey{TenantID: tenant.DefaultID}))
+	})
+
+	t.Run("auth-disabled", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(nil))
+	})
+
+	t.Run("other-tenant", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve(&apikey.APIKey{TenantID: "01J00000000000000000000000"}))
+	})
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// parseTimestamp reads an optional unix timestamp query parameter
+func parseTimestamp(q url.Values, name string) (*int64, error) {
+	s := q.Get(name)
+	if s == "" {
+		return nil, nil
+	}
+
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a unix timestamp", name)
+	}
+
+	return &v, nil
+}
+
+// parseLimit reads the page size from the limit query parameter
+func parseLimit(q url.Values) (int, error) {
+	s := q.Get("limit")
+	if s == "" {
+		return defaultPageSize, nil
+	}
+
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit < 1 || limit > maxPageSize {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+	}
+
+	return limit, nil
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/stuckinforloop/ticker/internal/lease"
+	taskexec "github.com/stuckinforloop/ticker/internal/task_exec"
+	"go.uber.org/zap"
+)
+
+func (a *API) GetLeader(w http.ResponseWriter, r *http.Request) *Response {
+	leaseDAO := lease.NewLeaseDAO(a.dao)
+
+	l, err := leaseDAO.GetLease(r.Context(), taskexec.LeaderLease)
+	if err != nil {
+		a.dao.Logger.Error("get leader lease", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	if l == nil || l.ExpiresAt <= a.dao.TimeNow() {
+		return &Response{
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New("no scheduler is leading"),
+		}
+	}
+
+	return &Response{
+		StatusCode: http.StatusOK,
+		Data:       l,
+	}
+}
+
+type GetShardsResponse struct {
+	Instances []lease.Instance `json:"instances"`
+	Shards    []lease.Lease    `json:"shards"`
+}
+
+func (a *API) GetShards(w http.ResponseWriter, r *http.Request) *Response {
+	leaseDAO := lease.NewLeaseDAO(a.dao)
+
+	now := a.dao.TimeNow()
+	instances, err := leaseDAO.ListInstances(r.Context(), 0)
+	if err != nil {
+		a.dao.Logger.Error("list scheduler instances", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	leases, err := leaseDAO.ListLeases(r.Context(), taskexec.ShardLeasePrefix)
+	if err != nil {
+		a.dao.Logger.Error("list shard leases", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	shards := []lease.Lease{}
+	for _, l := range leases {
+		if l.ExpiresAt > now {
+			shards = append(shards, l)
+		}
+	}
+
+	return &Response{
+		StatusCode: http.StatusOK,
+		Data: GetShardsResponse{
+			Instances: instances,
+			Shards:    shards,
+		},
+	}
+}
@@ -4,17 +4,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
 	"github.com/gitploy-io/cronexpr"
 	"github.com/go-chi/chi/v5"
+	"github.com/stuckinforloop/ticker/internal/quota"
 	"github.com/stuckinforloop/ticker/internal/task"
 	taskexec "github.com/stuckinforloop/ticker/internal/task_exec"
 	"go.uber.org/zap"
 )
 
-func validateTask(payload *task.Task) error {
+func validateTask(payload *task.Task, tenantID string) error {
 	if payload.Expression == "" {
 		return errors.New("expression is required")
 	} else {
@@ -23,20 +25,101 @@ func validateTask(payload *task.Task) error {
 		}
 	}
 
-	// TODO: validate URL
-	if payload.URL == "" {
-		return errors.New("url is required")
+	if _, err := payload.Timezone.Location(); err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
 	}
 
-	if payload.HTTPMethod == "" {
-		return errors.New("http method is required")
+	switch payload.Type {
+	case "", task.TypeHTTP:
+		// TODO: validate URL
+		if payload.URL == "" {
+			return errors.New("url is required")
+		}
+
+		if payload.HTTPMethod == "" {
+			return errors.New("http method is required")
+		}
+	default:
+		if payload.URL != "" || payload.HTTPMethod != "" || payload.SuccessCriteria != nil {
+			return fmt.Errorf("url, http_method and success_criteria do not apply to %s tasks", payload.Type)
+		}
+	}
+
+	if err := payload.ValidateConfig(tenantID); err != nil {
+		return err
+	}
+
+	if payload.RetryAfter != nil && *payload.RetryAfter < 0 {
+		return errors.New("retry_after cannot be negative")
+	}
+
+	switch payload.RetryBackoff {
+	case "", task.BackoffFixed, task.BackoffExponential:
+	default:
+		return fmt.Errorf("invalid retry_backoff: %s", payload.RetryBackoff)
+	}
+
+	if payload.MaxAttempts != nil && *payload.MaxAttempts < 1 {
+		return errors.New("max_attempts must be at least 1")
+	}
+
+	switch payload.MisfirePolicy {
+	case "", task.MisfireSkip, task.MisfireFireOnce, task.MisfireFireAll:
+	default:
+		return fmt.Errorf("invalid misfire_policy: %s", payload.MisfirePolicy)
+	}
+
+	if payload.MisfireLimit != nil &&
+		(*payload.MisfireLimit < 1 || *payload.MisfireLimit > task.MaxMisfireLimit) {
+		return fmt.Errorf("misfire_limit must be between 1 and %d", task.MaxMisfireLimit)
+	}
+
+	if payload.Instances != nil && *payload.Instances < 0 {
+		return errors.New("instances cannot be negative")
+	}
+
+	switch payload.ConcurrencyPolicy {
+	case "", task.ConcurrencyAllow, task.ConcurrencyForbid, task.ConcurrencyReplace:
+	default:
+		return fmt.Errorf("invalid concurrency_policy: %s", payload.ConcurrencyPolicy)
+	}
+
+	if err := payload.SuccessCriteria.Validate(); err != nil {
+		return fmt.Errorf("invalid success_criteria: %w", err)
+	}
+
+	return nil
+}
+
+// validateGroup checks that the group of the task exists
+func (a *API) validateGroup(r *http.Request, t *task.Task) *Response {
+	if t.GroupID == nil {
+		return nil
+	}
+
+	taskDAO := task.NewTaskDAO(a.dao, tenantID(r))
+	g, err := taskDAO.GetGroup(r.Context(), *t.GroupID)
+	if err != nil {
+		a.dao.Logger.Error("get group", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	if g == nil {
+		return &Response{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("group not found: %s", *t.GroupID),
+		}
 	}
 
 	return nil
 }
 
 func (a *API) CreateTask(w http.ResponseWriter, r *http.Request) *Response {
-	taskDAO := task.NewTaskDAO(a.dao)
+	taskDAO := task.NewTaskDAO(a.dao, tenantID(r))
 
 	payload := &task.Task{}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
@@ -48,7 +131,7 @@ func (a *API) CreateTask(w http.ResponseWriter, r *http.Request) *Response {
 		}
 	}
 
-	if err := validateTask(payload); err != nil {
+	if err := validateTask(payload, tenantID(r)); err != nil {
 		return &Response{
 			StatusCode: http.StatusBadRequest,
 			Err:        err,
@@ -56,8 +139,19 @@ func (a *API) CreateTask(w http.ResponseWriter, r *http.Request) *Response {
 	}
 	payload.HTTPMethod = strings.ToUpper(payload.HTTPMethod)
 
+	if resp := a.validateGroup(r, payload); resp != nil {
+		return resp
+	}
+
 	t, err := taskDAO.CreateTask(r.Context(), payload)
 	if err != nil {
+		if quota.IsExceeded(err) {
+			return &Response{
+				StatusCode: http.StatusForbidden,
+				Err:        err,
+			}
+		}
+
 		a.dao.Logger.Error("create task", zap.Error(err))
 
 		return &Response{
@@ -78,7 +172,7 @@ type GetTaskResponse struct {
 }
 
 func (a *API) GetTask(w http.ResponseWriter, r *http.Request) *Response {
-	taskDAO := task.NewTaskDAO(a.dao)
+	taskDAO := task.NewTaskDAO(a.dao, tenantID(r))
 	taskExecDAO := taskexec.NewTaskExecDAO(a.dao)
 
 	id := chi.URLParam(r, "id")
@@ -99,7 +193,7 @@ func (a *API) GetTask(w http.ResponseWriter, r *http.Request) *Response {
 		}
 	}
 
-	execs, err := taskExecDAO.ListTaskExecs(r.Context(), t.ID, 10, 0)
+	execs, err := taskExecDAO.ListRecentTaskExecs(r.Context(), t.TenantID, t.ID, 10)
 	if err != nil {
 		a.dao.Logger.Error("list task_exec", zap.Error(err))
 	}
@@ -114,15 +208,87 @@ func (a *API) GetTask(w http.ResponseWriter, r *http.Request) *Response {
 }
 
 type GetTasksResponse struct {
-	Tasks []task.Task `json:"tasks"`
+	Tasks      []task.Task `json:"tasks"`
+	Total      int64       `json:"total"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// parseTaskFilter reads the filters of the tasks listing from the query
+// parameters status (comma separated), group_id, name, url_host,
+// created_from, created_to, updated_from, updated_to, sort (one of
+// created_at, updated_at and name, prefixed with - for descending
+// order), cursor and limit
+func parseTaskFilter(r *http.Request) (task.ListFilter, error) {
+	q := r.URL.Query()
+	f := task.ListFilter{
+		GroupID: q.Get("group_id"),
+		Name:    q.Get("name"),
+		URLHost: q.Get("url_host"),
+		Cursor:  q.Get("cursor"),
+	}
+
+	if s := q.Get("status"); s != "" {
+		for _, status := range strings.Split(s, ",") {
+			switch task.Status(status) {
+			case task.StatusActive, task.StatusDisabled, task.StatusExpired:
+			default:
+				return f, fmt.Errorf("invalid status: %s", status)
+			}
+
+			f.Statuses = append(f.Statuses, task.Status(status))
+		}
+	}
+
+	var err error
+	for name, dest := range map[string]**int64{
+		"created_from": &f.CreatedFrom,
+		"created_to":   &f.CreatedTo,
+		"updated_from": &f.UpdatedFrom,
+		"updated_to":   &f.UpdatedTo,
+	} {
+		if *dest, err = parseTimestamp(q, name); err != nil {
+			return f, err
+		}
+	}
+
+	if s := q.Get("sort"); s != "" {
+		f.Desc = strings.HasPrefix(s, "-")
+		f.Sort = task.SortField(strings.TrimPrefix(s, "-"))
+		switch f.Sort {
+		case task.SortCreatedAt, task.SortUpdatedAt, task.SortName:
+		default:
+			return f, fmt.Errorf("invalid sort: %s", s)
+		}
+	}
+
+	if f.Limit, err = parseLimit(q); err != nil {
+		return f, err
+	}
+
+	return f, nil
 }
 
 func (a *API) GetTasks(w http.ResponseWriter, r *http.Request) *Response {
-	taskDAO := task.NewTaskDAO(a.dao)
+	taskDAO := task.NewTaskDAO(a.dao, tenantID(r))
 
-	tasks, err := taskDAO.GetTasks(r.Context())
+	f, err := parseTaskFilter(r)
 	if err != nil {
-		a.dao.Logger.Error("get tasks", zap.Error(err))
+		return &Response{
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+
+	tasks, cursor, total, err := taskDAO.ListTasks(r.Context(), f)
+	if err != nil {
+		if errors.Is(err, task.ErrInvalidCursor) {
+			return &Response{
+				StatusCode: http.StatusBadRequest,
+				Err:        err,
+			}
+		}
+
+		a.dao.Logger.Error("list tasks", zap.Error(err))
 
 		return &Response{
 			StatusCode: http.StatusInternalServerError,
@@ -132,14 +298,35 @@ func (a *API) GetTasks(w http.ResponseWriter, r *http.Request) *Response {
 
 	return &Response{
 		StatusCode: http.StatusOK,
-		Data:       GetTasksResponse{Tasks: tasks},
+		Data: GetTasksResponse{
+			Tasks:      tasks,
+			Total:      total,
+			NextCursor: cursor,
+		},
 	}
 }
 
+// UpdateTask applies a JSON merge patch to the task, fields missing
+// from the body are left untouched and null resets a field
 func (a *API) UpdateTask(w http.ResponseWriter, r *http.Request) *Response {
-	taskDAO := task.NewTaskDAO(a.dao)
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		a.dao.Logger.Warn("read req body", zap.Error(err))
 
-	id := chi.URLParam(r, "id")
+		return &Response{
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+
+	return a.updateTask(r, func(current *task.Task) (*task.Task, error) {
+		return task.MergePatch(current, patch)
+	})
+}
+
+// ReplaceTask replaces every field of the task with the body, fields
+// missing from the body are set to their default
+func (a *API) ReplaceTask(w http.ResponseWriter, r *http.Request) *Response {
 	payload := &task.Task{}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		a.dao.Logger.Warn("parse req body", zap.Error(err))
@@ -149,9 +336,65 @@ func (a *API) UpdateTask(w http.ResponseWriter, r *http.Request) *Response {
 			Err:        err,
 		}
 	}
-	payload.ID = id
 
-	if err := taskDAO.UpdateTask(r.Context(), payload); err != nil {
+	return a.updateTask(r, func(current *task.Task) (*task.Task, error) {
+		payload.CopyReadOnly(current)
+		return payload, nil
+	})
+}
+
+// updateTask writes the task returned by apply for the current task
+// once it has been validated
+func (a *API) updateTask(r *http.Request, apply func(current *task.Task) (*task.Task, error)) *Response {
+	taskDAO := task.NewTaskDAO(a.dao, tenantID(r))
+
+	id := chi.URLParam(r, "id")
+	current, err := taskDAO.GetTask(r.Context(), id)
+	if err != nil {
+		a.dao.Logger.Error("get task", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	if current == nil {
+		return &Response{
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New("task not found"),
+		}
+	}
+
+	t, err := apply(current)
+	if err != nil {
+		return &Response{
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+
+	if err := validateTask(t, tenantID(r)); err != nil {
+		return &Response{
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+	t.HTTPMethod = strings.ToUpper(t.HTTPMethod)
+
+	if resp := a.validateGroup(r, t); resp != nil {
+		return resp
+	}
+
+	updated, err := taskDAO.UpdateTask(r.Context(), t)
+	if err != nil {
+		if quota.IsExceeded(err) {
+			return &Response{
+				StatusCode: http.StatusForbidden,
+				Err:        err,
+			}
+		}
+
 		a.dao.Logger.Error("update task", zap.Error(err))
 
 		return &Response{
@@ -160,13 +403,22 @@ func (a *API) UpdateTask(w http.ResponseWriter, r *http.Request) *Response {
 		}
 	}
 
+	// deleted since it was read
+	if updated == nil {
+		return &Response{
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New("task not found"),
+		}
+	}
+
 	return &Response{
 		StatusCode: http.StatusOK,
+		Data:       updated,
 	}
 }
 
 func (a *API) DeleteTask(w http.ResponseWriter, r *http.Request) *Response {
-	taskDAO := task.NewTaskDAO(a.dao)
+	taskDAO := task.NewTaskDAO(a.dao, tenantID(r))
 
 	id := chi.URLParam(r, "id")
 	if err := taskDAO.DeleteTask(r.Context(), id); err != nil {
@@ -182,3 +434,192 @@ func (a *API) DeleteTask(w http.ResponseWriter, r *http.Request) *Response {
 		StatusCode: http.StatusOK,
 	}
 }
+
+func (a *API) EnableTask(w http.ResponseWriter, r *http.Request) *Response {
+	taskDAO := task.NewTaskDAO(a.dao, tenantID(r))
+
+	id := chi.URLParam(r, "id")
+	t, err := taskDAO.EnableTask(r.Context(), id)
+	if err != nil {
+		a.dao.Logger.Error("enable task", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	if t == nil {
+		return &Response{
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New("task not found"),
+		}
+	}
+
+	return &Response{
+		StatusCode: http.StatusOK,
+		Data:       t,
+	}
+}
+
+// RunTask triggers an execution of the task right away. The body may
+// override the http headers and post data of the task for this run.
+func (a *API) RunTask(w http.ResponseWriter, r *http.Request) *Response {
+	taskDAO := task.NewTaskDAO(a.dao, tenantID(r))
+	taskExecDAO := taskexec.NewTaskExecDAO(a.dao)
+
+	overrides := taskexec.RunOverrides{}
+	if err := json.NewDecoder(r.Body).Decode(&overrides); err != nil && !errors.Is(err, io.EOF) {
+		a.dao.Logger.Warn("parse req body", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+
+	id := chi.URLParam(r, "id")
+	t, err := taskDAO.GetTask(r.Context(), id)
+	if err != nil {
+		a.dao.Logger.Error("get task", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	if t == nil {
+		return &Response{
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New("task not found"),
+		}
+	}
+
+	if err := overrides.Validate(*t); err != nil {
+		return &Response{
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+
+	exec, err := taskExecDAO.RunTask(r.Context(), *t, overrides)
+	if err != nil {
+		if quota.IsExceeded(err) {
+			return &Response{
+				StatusCode: http.StatusTooManyRequests,
+				Err:        err,
+			}
+		}
+
+		a.dao.Logger.Error("run task", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	return &Response{
+		StatusCode: http.StatusAccepted,
+		Data:       exec,
+	}
+}
+
+// parsePause reads the optional pause details from the body
+func (a *API) parsePause(r *http.Request) (*task.Pause, error) {
+	p := &task.Pause{}
+	if err := json.NewDecoder(r.Body).Decode(p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if err := p.Validate(a.dao.TimeNow()); err != nil {
+		return nil, err
+	}
+
+	// paused by the owner of the api key
+	if k := apiKey(r); k != nil {
+		p.PausedBy = &k.Name
+	}
+
+	return p, nil
+}
+
+// PauseTask disables the task until it is resumed. The body records why
+// the task was paused and may set resume_at to resume it later, the
+// task is recorded as paused by the api key.
+func (a *API) PauseTask(w http.ResponseWriter, r *http.Request) *Response {
+	taskDAO := task.NewTaskDAO(a.dao, tenantID(r))
+
+	p, err := a.parsePause(r)
+	if err != nil {
+		return &Response{
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+
+	t, err := taskDAO.PauseTask(r.Context(), chi.URLParam(r, "id"), *p)
+	if err != nil {
+		if errors.Is(err, task.ErrNotPausable) {
+			return &Response{
+				StatusCode: http.StatusConflict,
+				Err:        err,
+			}
+		}
+
+		a.dao.Logger.Error("pause task", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	if t == nil {
+		return &Response{
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New("task not found"),
+		}
+	}
+
+	return &Response{
+		StatusCode: http.StatusOK,
+		Data:       t,
+	}
+}
+
+// ResumeTask enables the task if it was paused, it conflicts with
+// tasks disabled for another reason
+func (a *API) ResumeTask(w http.ResponseWriter, r *http.Request) *Response {
+	taskDAO := task.NewTaskDAO(a.dao, tenantID(r))
+
+	t, err := taskDAO.ResumeTask(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, task.ErrNotPaused) {
+			return &Response{
+				StatusCode: http.StatusConflict,
+				Err:        err,
+			}
+		}
+
+		a.dao.Logger.Error("resume task", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	if t == nil {
+		return &Response{
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New("task not found"),
+		}
+	}
+
+	return &Response{
+		StatusCode: http.StatusOK,
+		Data:       t,
+	}
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oklog/ulid/v2"
+	"github.com/stuckinforloop/ticker/internal/task"
+	taskexec "github.com/stuckinforloop/ticker/internal/task_exec"
+	"go.uber.org/zap"
+)
+
+type GetTaskExecStatsResponse struct {
+	Since  int64                     `json:"since"`
+	Counts map[taskexec.Status]int64 `json:"counts"`
+}
+
+// GetTaskExecStats returns the number of executions by status, including
+// the executions reaped as timed out or lost. The optional since query
+// parameter only counts executions due at or after that unix time.
+func (a *API) GetTaskExecStats(w http.ResponseWriter, r *http.Request) *Response {
+	taskExecDAO := taskexec.NewTaskExecDAO(a.dao)
+
+	var since int64
+	if s := r.URL.Query().Get("since"); s != "" {
+		var err error
+		since, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || since < 0 {
+			return &Response{
+				StatusCode: http.StatusBadRequest,
+				Err:        errors.New("since must be a unix timestamp"),
+			}
+		}
+	}
+
+	counts, err := taskExecDAO.CountTaskExecs(r.Context(), tenantID(r), since)
+	if err != nil {
+		a.dao.Logger.Error("count task_execs", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	return &Response{
+		StatusCode: http.StatusOK,
+		Data: GetTaskExecStatsResponse{
+			Since:  since,
+			Counts: counts,
+		},
+	}
+}
+
+// parseListFilter reads the filters of the executions listing from the
+// query parameters status (comma separated), run_at_from, run_at_to,
+// manual, cursor and limit
+func parseListFilter(r *http.Request) (taskexec.ListFilter, error) {
+	q := r.URL.Query()
+	f := taskexec.ListFilter{TenantID: tenantID(r)}
+
+	if s := q.Get("status"); s != "" {
+		for _, status := range strings.Split(s, ",") {
+			if !slices.Contains(taskexec.Statuses, taskexec.Status(status)) {
+				return f, fmt.Errorf("invalid status: %s", status)
+			}
+
+			f.Statuses = append(f.Statuses, taskexec.Status(status))
+		}
+	}
+
+	var err error
+	if f.RunAtFrom, err = parseTimestamp(q, "run_at_from"); err != nil {
+		return f, err
+	}
+
+	if f.RunAtTo, err = parseTimestamp(q, "run_at_to"); err != nil {
+		return f, err
+	}
+
+	if s := q.Get("manual"); s != "" {
+		manual, err := strconv.ParseBool(s)
+		if err != nil {
+			return f, errors.New("manual must be true or false")
+		}
+		f.Manual = &manual
+	}
+
+	if s := q.Get("cursor"); s != "" {
+		if _, err := ulid.ParseStrict(s); err != nil {
+			return f, errors.New("invalid cursor")
+		}
+		f.Cursor = s
+	}
+
+	if f.Limit, err = parseLimit(q); err != nil {
+		return f, err
+	}
+
+	return f, nil
+}
+
+type GetTaskExecsResponse struct {
+	TaskExecs  []taskexec.TaskExec `json:"executions"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+// GetTaskExecs lists the executions of every task, see parseListFilter
+// for the supported filters
+func (a *API) GetTaskExecs(w http.ResponseWriter, r *http.Request) *Response {
+	f, err := parseListFilter(r)
+	if err != nil {
+		return &Response{
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+
+	return a.listTaskExecs(r, f)
+}
+
+// GetTaskTaskExecs lists the executions of a single task
+func (a *API) GetTaskTaskExecs(w http.ResponseWriter, r *http.Request) *Response {
+	taskDAO := task.NewTaskDAO(a.dao, tenantID(r))
+
+	f, err := parseListFilter(r)
+	if err != nil {
+		return &Response{
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+
+	id := chi.URLParam(r, "id")
+	t, err := taskDAO.GetTask(r.Context(), id)
+	if err != nil {
+		a.dao.Logger.Error("get task", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	if t == nil {
+		return &Response{
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New("task not found"),
+		}
+	}
+
+	f.TaskID = t.ID
+	return a.listTaskExecs(r, f)
+}
+
+func (a *API) listTaskExecs(r *http.Request, f taskexec.ListFilter) *Response {
+	taskExecDAO := taskexec.NewTaskExecDAO(a.dao)
+
+	execs, cursor, err := taskExecDAO.ListTaskExecs(r.Context(), f)
+	if err != nil {
+		a.dao.Logger.Error("list task_execs", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	return &Response{
+		StatusCode: http.StatusOK,
+		Data: GetTaskExecsResponse{
+			TaskExecs:  execs,
+			NextCursor: cursor,
+		},
+	}
+}
+
+func (a *API) GetTaskExec(w http.ResponseWriter, r *http.Request) *Response {
+	taskExecDAO := taskexec.NewTaskExecDAO(a.dao)
+
+	id := chi.URLParam(r, "id")
+	te, err := taskExecDAO.GetTaskExec(r.Context(), tenantID(r), id)
+	if err != nil {
+		a.dao.Logger.Error("get task_exec", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	if te == nil {
+		return &Response{
+			StatusCode: http.StatusNotFound,
+			Err:        errors.New("execution not found"),
+		}
+	}
+
+	return &Response{
+		StatusCode: http.StatusOK,
+		Data:       te,
+	}
+}
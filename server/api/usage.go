@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/stuckinforloop/ticker/internal/quota"
+	"github.com/stuckinforloop/ticker/internal/task"
+	taskexec "github.com/stuckinforloop/ticker/internal/task_exec"
+	"go.uber.org/zap"
+)
+
+// Usage is the consumption of a quota, a limit of 0 is unlimited
+type Usage struct {
+	Used  int64 `json:"used"`
+	Limit int   `json:"limit"`
+}
+
+type GetUsageResponse struct {
+	Tasks             Usage `json:"tasks"`
+	ExecutionsPerHour Usage `json:"executions_per_hour"`
+	MinInterval       int   `json:"min_interval"`
+	MaxTimeout        int   `json:"max_timeout"`
+}
+
+// GetUsage returns the number of tasks of the tenant and the executions
+// fired in the last hour along with the quotas of the tenant
+func (a *API) GetUsage(w http.ResponseWriter, r *http.Request) *Response {
+	tenantID := tenantID(r)
+	taskDAO := task.NewTaskDAO(a.dao, tenantID)
+	taskExecDAO := taskexec.NewTaskExecDAO(a.dao)
+
+	q, err := quota.NewQuotaDAO(a.dao).GetQuotas(r.Context(), tenantID)
+	if err != nil {
+		a.dao.Logger.Error("get quotas", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	tasks, err := taskDAO.CountTasks(r.Context())
+	if err != nil {
+		a.dao.Logger.Error("count tasks", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	execs, err := taskExecDAO.CountExecutions(r.Context(), tenantID, a.dao.TimeNow()-3600)
+	if err != nil {
+		a.dao.Logger.Error("count task_execs", zap.Error(err))
+
+		return &Response{
+			StatusCode: http.StatusInternalServerError,
+			Err:        err,
+		}
+	}
+
+	return &Response{
+		StatusCode: http.StatusOK,
+		Data: GetUsageResponse{
+			Tasks:             Usage{Used: tasks, Limit: q.MaxTasks},
+			ExecutionsPerHour: Usage{Used: execs, Limit: q.MaxExecutionsPerHour},
+			MinInterval:       q.MinInterval,
+			MaxTimeout:        q.MaxTimeout,
+		},
+	}
+}
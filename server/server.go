@@ -42,6 +42,11 @@ func (s *Server) Start() (err error) {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
 	defer stop()
 
+	return s.Serve(ctx)
+}
+
+// Serve listens for requests until ctx is cancelled or the server fails.
+func (s *Server) Serve(ctx context.Context) (err error) {
 	api := api.New(s.DAO)
 	api.RegisterRoutes()
 
@@ -65,9 +70,8 @@ func (s *Server) Start() (err error) {
 
 	select {
 	case err = <-serverErr:
-
+		return
 	case <-ctx.Done():
-		stop()
 	}
 
 	s.DAO.Logger.Warn("received sigterm/interrupt signal, shutting down server...")
@@ -32,20 +32,24 @@ func New() *Worker {
 	return &Worker{dao}
 }
 
-func (s *Worker) Run(handler func(ctx context.Context) error) {
+// Run starts every handler in its own goroutine and blocks until
+// SIGINT/SIGTERM is received, then waits for all handlers to return.
+func (s *Worker) Run(handlers ...func(ctx context.Context) error) {
 	s.DAO.Logger.Info("starting worker")
 
 	ctx := context.Background()
 	ctx, ctxCancel := context.WithCancel(ctx)
 
 	wg := sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		if err := handler(ctx); err != nil {
-			panic(err)
-		}
-	}()
+	for _, handler := range handlers {
+		wg.Add(1)
+		go func(handler func(ctx context.Context) error) {
+			defer wg.Done()
+			if err := handler(ctx); err != nil {
+				panic(err)
+			}
+		}(handler)
+	}
 
 	c := make(chan os.Signal, 1)
 